@@ -11,6 +11,7 @@ require (
 	github.com/panjf2000/ants/v2 v2.7.1
 	github.com/pkg/errors v0.9.1
 	github.com/saintfish/chardet v0.0.0-20230101081208-5e3ef4b5456d
+	github.com/tdewolff/parse/v2 v2.7.12
 	golang.org/x/net v0.8.0
 )
 
@@ -7,11 +7,14 @@ import (
 	engine2 "github.com/sairson/crawlergo/internal/engine"
 	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
 	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
 	"github.com/sairson/crawlergo/internal/expression"
 	"github.com/sairson/crawlergo/internal/filter"
+	"github.com/sairson/crawlergo/internal/graphql"
 	"github.com/sairson/crawlergo/internal/option"
+	"github.com/sairson/crawlergo/internal/sourcemap"
+	"github.com/sairson/crawlergo/internal/store"
 	"github.com/sairson/crawlergo/pkg/utils"
-	"strings"
 	"sync"
 	"time"
 )
@@ -23,38 +26,64 @@ type Crawler struct {
 	Targets             []*httplib.RequestCrawler
 	WaitGroup           sync.WaitGroup
 	Option              *option.TaskOptions
-	SmartFilter         filter.SmartFilter                    // 过滤对象
+	Filter              filter.Filter                         // 过滤对象,按照FilterMode创建,爬取时为当前设备配置使用的过滤器
+	DeviceFilters       []filter.Filter                       // 每个设备配置使用的独立过滤器,与Devices一一对应
+	HostLimit           string                                // 过滤器限制的域名
 	ResultCallback      func(i *httplib.RequestCrawler) error // 结果回调函数
 	Result              CrawlerResult                         // 爬虫最终结果
-	CrawlerAlreadyCount int                                   // 已经爬取过的总数
+	CrawlerAlreadyCount int                                   // 已经爬取过的总数,全部设备配置共享
 	CrawlerCountLock    sync.Mutex                            // 爬虫总数锁
+	Devices             []enums2.DeviceProfile                // 需要模拟的设备配置列表
+	Device              enums2.DeviceProfile                  // 当前爬取使用的设备配置
+	UploadDir           *engine2.UploadDir                    // 文件上传样例文件的临时目录,第一次上传文件时创建
+	BodyStore           *store.BodyStore                      // 响应体存储
+	GraphQLOperationSet mapset.Set                            // GraphQL操作去重
+	SourceMapSet        mapset.Set                            // source map去重
+	WorkerScriptSet     mapset.Set                            // worker脚本去重
+	ParamCollect        *ParamCollect                         // 参数名收集
+	NearDuplicate       *filter.NearDuplicate                 // 相似页面检测,未开启时为空
+	FilterReport        *filter.Report                        // 过滤报告,未开启时为空
 }
 
 type CrawlerResult struct {
-	RequestList           []*httplib.RequestCrawler // 返回的同域名结果
-	AllRequestList        []*httplib.RequestCrawler // 所有域名的请求
-	AllDomainList         []string                  // 所有域名列表
-	SubDomainList         []string                  // 子域名列表
-	MergeResultAttachLock sync.Mutex                // 合并结果时的加锁
+	RequestList           []*httplib.RequestCrawler       // 返回的同域名结果
+	AllRequestList        []*httplib.RequestCrawler       // 所有域名的请求
+	AllDomainList         []string                        // 所有域名列表
+	SubDomainList         []string                        // 子域名列表
+	FormList              []*httplib.Form                 // 所有页面中解析出来的表单
+	StateGraphList        []*engine2.StateGraph           // 状态探索得到的页面状态图
+	SkippedElementList    []*engine2.SkippedElement       // 匹配危险关键字而跳过的元素
+	WebSocketList         []*engine2.WebSocketRecord      // 捕获的WebSocket连接和消息帧
+	GraphQLOperationList  []*graphql.Operation            // 按照操作输出的GraphQL结果
+	SourceMapList         []*sourcemap.Record             // 还原的source map和其中的敏感信息
+	ParamList             []*ParamEntry                   // 全站去重的参数字典,按照出现次数排列
+	EndpointParamList     []*EndpointParams               // 每个地址的参数清单
+	HeaderURLList         []*engine2.HeaderURL            // 响应头中解析出来的地址和域名
+	FilterMemoryList      []*filter.MemoryUsage           // 每个设备爬取结束时过滤器估算的内存占用
+	NearDuplicateList     []*filter.NearDuplicateTemplate // 因为页面几乎相同而停止扩展的地址模板
+	FilterReport          []*filter.ReasonGroup           // 按照原因分组的被过滤请求
+	MergeResultAttachLock sync.Mutex                      // 合并结果时的加锁
 }
 
 type TabCrawler struct {
 	crawler *Crawler                // 爬虫
 	browser *engine2.Browser        // 浏览器
 	request *httplib.RequestCrawler // 请求
+	device  enums2.DeviceProfile    // 设备配置
 }
 
 // NewTabCrawlerGoTask 新建一个tab页爬虫事件
 func NewTabCrawlerGoTask(targets []*httplib.RequestCrawler, options option.TaskOptions) (*Crawler, error) {
 	var crawler = &Crawler{
-		Option: &options,
-		SmartFilter: filter.SmartFilter{
-			SimpleFilter: filter.SimpleFilter{},
-		},
+		Option:              &options,
+		GraphQLOperationSet: mapset.NewSet(),
+		SourceMapSet:        mapset.NewSet(),
+		WorkerScriptSet:     mapset.NewSet(),
+		ParamCollect:        NewParamCollect(),
 	}
 	// 如果我们的目标数量 > 0
 	if len(targets) > 0 {
-		crawler.SmartFilter.SimpleFilter.HostLimit = targets[0].URL.Host
+		crawler.HostLimit = targets[0].URL.Host
 	}
 	if len(targets) == 1 {
 		_newReq := *targets[0]
@@ -83,9 +112,27 @@ func NewTabCrawlerGoTask(targets []*httplib.RequestCrawler, options option.TaskO
 		crawler.WithBeforeExitDelay(enums2.BeforeExitDelay),
 		crawler.WithEventTriggerMode(enums2.DefaultEventTriggerMode),
 		crawler.WithIgnoreKeywords(enums2.DefaultIgnoreKeywords),
+		crawler.WithDangerKeywords(enums2.DefaultDangerKeywords),
+		crawler.WithArchiveMaxSize(enums2.ArchiveMaxSize),
+		crawler.WithExploreMaxActions(enums2.ExploreMaxActions),
+		crawler.WithScrollMaxCount(enums2.ScrollMaxCount),
+		crawler.WithBodyStoreMaxSize(enums2.DefaultBodyStoreMaxSize),
+		crawler.WithBodyStoreDenyMime(enums2.DefaultBodyStoreDenyMime),
+		crawler.WithWebSocketMaxFrames(enums2.WebSocketMaxFrames),
+		crawler.WithFilterMode(filter.ModeSmart),
+		crawler.WithFilterFalsePositive(enums2.DefaultFilterFalsePositive),
+		crawler.WithFilterStatMaxKeys(enums2.FilterStatMaxKeys),
+		crawler.WithNearDuplicateMaxPages(enums2.NearDuplicateMaxPages),
+		crawler.WithNearDuplicateDistance(enums2.NearDuplicateDistance),
 	} {
 		fn(&options)
 	}
+	if options.NearDuplicate {
+		crawler.NearDuplicate = filter.NewNearDuplicate(options.NearDuplicateMaxPages, options.NearDuplicateDistance)
+	}
+	if options.FilterReport {
+		crawler.FilterReport = filter.NewReport(enums2.FilterReportMaxSamples)
+	}
 	// 初始化请求头字符串
 	if options.ExtraHeadersString != "" {
 		err := json.Unmarshal([]byte(options.ExtraHeadersString), &options.ExtraHeaders)
@@ -93,12 +140,42 @@ func NewTabCrawlerGoTask(targets []*httplib.RequestCrawler, options option.TaskO
 			return nil, err
 		}
 	}
+	// 加载设备配置
+	var customDevices map[string]enums2.DeviceProfile
+	if options.DeviceProfilePath != "" {
+		devices, err := engine2.LoadDeviceProfiles(options.DeviceProfilePath)
+		if err != nil {
+			return nil, err
+		}
+		customDevices = devices
+	}
+	devices, err := engine2.GetDeviceProfiles(options.DeviceProfiles, customDevices)
+	if err != nil {
+		return nil, err
+	}
+	crawler.Devices = devices
+	// 过滤器初始化,每个设备配置使用独立的过滤器,未注册的过滤模式直接返回错误
+	for range crawler.Devices {
+		f, err := filter.New(options.FilterMode, crawler.FilterConfig())
+		if err != nil {
+			return nil, err
+		}
+		crawler.DeviceFilters = append(crawler.DeviceFilters, f)
+	}
+	crawler.Filter = crawler.DeviceFilters[0]
+	// 文件上传样例文件目录,第一次需要样例文件时才创建
+	crawler.UploadDir = engine2.NewUploadDir()
+	// 创建响应体存储
+	if options.BodyStoreDir != "" {
+		crawler.BodyStore, err = store.NewBodyStore(options.BodyStoreDir, options.BodyStoreMaxSize, options.BodyStoreAllowMime, options.BodyStoreDenyMime)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// 初始化浏览器
-	crawler.Browser, _ = engine2.InitBrowser(options.ChromiumPath, options.ExtraHeaders, options.Proxy, options.NoHeadless)
+	crawler.Browser, _ = engine2.InitBrowser(options.ChromiumPath, options.ExtraHeaders, options.Proxy, options.NoHeadless, options.DisableSiteIsolation)
 	// 初始化我们的根域名
 	crawler.RootDomain = targets[0].URL.RootDomain()
-	// 智能过滤器初始化
-	crawler.SmartFilter.Init()
 
 	// 创建协程池
 	p, _ := ants.NewPool(options.MaxTabCount)
@@ -107,6 +184,48 @@ func NewTabCrawlerGoTask(targets []*httplib.RequestCrawler, options option.TaskO
 	return crawler, nil
 }
 
+// AddGraphQLOperations 添加GraphQL操作到结果中,相同地址的同一个操作只记录一次
+func (crawler *Crawler) AddGraphQLOperations(operations []*graphql.Operation) {
+	crawler.Result.MergeResultAttachLock.Lock()
+	defer crawler.Result.MergeResultAttachLock.Unlock()
+	for _, op := range operations {
+		if crawler.GraphQLOperationSet.Add(op.Key()) {
+			crawler.Result.GraphQLOperationList = append(crawler.Result.GraphQLOperationList, op)
+		}
+	}
+}
+
+// IntrospectGraphQLEndpoints 向请求中发现的GraphQL地址发送内省查询,只查询限制域名内的地址,
+// 目标的请求头只发送给同源的地址
+func (crawler *Crawler) IntrospectGraphQLEndpoints() {
+	endpoints := mapset.NewSet()
+	for _, op := range crawler.Result.GraphQLOperationList {
+		if op.Endpoint != "" {
+			endpoints.Add(op.Endpoint)
+		}
+	}
+	target := crawler.Targets[0]
+	scope := &filter.SimpleFilter{HostLimit: crawler.HostLimit}
+	for _, endpoint := range endpoints.ToSlice() {
+		endpointURL, err := urllib.GetURL(endpoint.(string))
+		if err != nil {
+			continue
+		}
+		if crawler.HostLimit != "" && scope.DomainFilter(httplib.GetCrawlerRequest(enums2.GET, endpointURL)) {
+			continue
+		}
+		var headers map[string]string
+		if endpointURL.Scheme == target.URL.Scheme && endpointURL.Host == target.URL.Host {
+			headers = utils.ConvertHeaders(target.Headers)
+		}
+		operations, err := graphql.Introspect(endpoint.(string), headers, crawler.Option.Proxy)
+		if err != nil {
+			continue
+		}
+		crawler.AddGraphQLOperations(operations)
+	}
+}
+
 // WithTabRunTimeout 设置每一个tab页的运行超时
 func (crawler *Crawler) WithTabRunTimeout(gen time.Duration) option.TaskOptionOptFunc {
 	return func(tc *option.TaskOptions) {
@@ -134,7 +253,7 @@ func (crawler *Crawler) WithMaxTabsCount(gen int) option.TaskOptionOptFunc {
 	}
 }
 
-// WithEventTriggerMode 设置页面事件的触发方式async,sync
+// WithEventTriggerMode 设置页面事件的触发方式async,sync,trusted
 func (crawler *Crawler) WithEventTriggerMode(gen string) option.TaskOptionOptFunc {
 	return func(tc *option.TaskOptions) {
 		if tc.EventTriggerMode == "" {
@@ -170,6 +289,15 @@ func (crawler *Crawler) WithIgnoreKeywords(gen []string) option.TaskOptionOptFun
 	}
 }
 
+// WithDangerKeywords 设置危险操作关键字
+func (crawler *Crawler) WithDangerKeywords(gen []string) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.DangerKeywords == nil || len(tc.DangerKeywords) == 0 {
+			tc.DangerKeywords = gen
+		}
+	}
+}
+
 // WithDomContentLoadedTimeout 设置dom加载的超时
 func (crawler *Crawler) WithDomContentLoadedTimeout(gen time.Duration) option.TaskOptionOptFunc {
 	return func(tc *option.TaskOptions) {
@@ -179,9 +307,109 @@ func (crawler *Crawler) WithDomContentLoadedTimeout(gen time.Duration) option.Ta
 	}
 }
 
+// WithArchiveMaxSize 设置单个归档文件的最大字节数
+func (crawler *Crawler) WithArchiveMaxSize(gen int) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.ArchiveMaxSize == 0 {
+			tc.ArchiveMaxSize = gen
+		}
+	}
+}
+
+// WithExploreMaxActions 设置状态探索时每个tab页最多执行的动作数
+func (crawler *Crawler) WithExploreMaxActions(gen int) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.ExploreMaxActions == 0 {
+			tc.ExploreMaxActions = gen
+		}
+	}
+}
+
+// WithScrollMaxCount 设置无限滚动时最多滚动的次数
+func (crawler *Crawler) WithScrollMaxCount(gen int) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.ScrollMaxCount == 0 {
+			tc.ScrollMaxCount = gen
+		}
+	}
+}
+
+// WithBodyStoreMaxSize 设置每种MIME类型的响应体最大字节数
+func (crawler *Crawler) WithBodyStoreMaxSize(gen map[string]int) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.BodyStoreMaxSize == nil || len(tc.BodyStoreMaxSize) == 0 {
+			tc.BodyStoreMaxSize = gen
+		}
+	}
+}
+
+// WithBodyStoreDenyMime 设置不存储响应体的MIME类型
+func (crawler *Crawler) WithBodyStoreDenyMime(gen []string) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.BodyStoreDenyMime == nil || len(tc.BodyStoreDenyMime) == 0 {
+			tc.BodyStoreDenyMime = gen
+		}
+	}
+}
+
+// WithFilterMode 设置过滤模式
+func (crawler *Crawler) WithFilterMode(gen string) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.FilterMode == "" {
+			tc.FilterMode = gen
+		}
+	}
+}
+
+// WithFilterFalsePositive 设置布隆过滤器的误判率
+func (crawler *Crawler) WithFilterFalsePositive(gen float64) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.FilterFalsePositive == 0 {
+			tc.FilterFalsePositive = gen
+		}
+	}
+}
+
+// WithFilterStatMaxKeys 设置智能过滤最多记录的统计项数量
+func (crawler *Crawler) WithFilterStatMaxKeys(gen int) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.FilterStatMaxKeys == 0 {
+			tc.FilterStatMaxKeys = gen
+		}
+	}
+}
+
+// WithNearDuplicateMaxPages 设置相似页面检测的页面数量阈值
+func (crawler *Crawler) WithNearDuplicateMaxPages(gen int) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.NearDuplicateMaxPages == 0 {
+			tc.NearDuplicateMaxPages = gen
+		}
+	}
+}
+
+// WithNearDuplicateDistance 设置相似页面检测的最大汉明距离
+func (crawler *Crawler) WithNearDuplicateDistance(gen int) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.NearDuplicateDistance == 0 {
+			tc.NearDuplicateDistance = gen
+		}
+	}
+}
+
+// WithWebSocketMaxFrames 设置每个WebSocket连接最多记录的消息帧数
+func (crawler *Crawler) WithWebSocketMaxFrames(gen int) option.TaskOptionOptFunc {
+	return func(tc *option.TaskOptions) {
+		if tc.WebSocketMaxFrames == 0 {
+			tc.WebSocketMaxFrames = gen
+		}
+	}
+}
+
 func (crawler *Crawler) Run() {
 	defer crawler.Pool.Release()                // 释放爬虫使用的协程池
 	defer crawler.Browser.CloseTabsAndBrowser() // 关闭浏览器的所有标签页和自身
+	defer crawler.UploadDir.Remove()            // 删除文件上传样例文件
 
 	// 新建一个表达式处理
 	crawlerExpression := new(expression.CrawlerExpression)
@@ -207,23 +435,26 @@ func (crawler *Crawler) Run() {
 	}
 	crawler.Result.AllRequestList = crawler.Targets[:]
 
-	// 执行tab任务做深度的自动化爬虫
-	var initDeepCrawler []*httplib.RequestCrawler
-	for i := 0; i < len(crawler.Targets); i++ {
-		if crawler.SmartFilter.DoFilter(crawler.Targets[i]) {
-			continue
-		}
-		initDeepCrawler = append(initDeepCrawler, crawler.Targets[i])
-		crawler.Result.RequestList = append(crawler.Result.RequestList, crawler.Targets[i])
+	// 依次在每个设备配置下执行深度爬虫
+	for i, device := range crawler.Devices {
+		crawler.DeepCrawlerWithDevice(device, crawler.DeviceFilters[i])
+	}
+	// GraphQL内省查询
+	if crawler.Option.GraphQLIntrospection {
+		crawler.IntrospectGraphQLEndpoints()
 	}
 
-	// 执行更深层的tab页爬虫
-	for i := 0; i < len(initDeepCrawler); i++ {
-		if !engine2.IsIgnoredByKeywordMatch(*initDeepCrawler[i], crawler.Option.IgnoreKeywords) {
-			crawler.DeepCrawlerTaskPool(initDeepCrawler[i])
+	// 多个设备的结果合并后去重
+	if len(crawler.Devices) > 1 {
+		todoFilterList := crawler.Result.RequestList
+		crawler.Result.RequestList = []*httplib.RequestCrawler{}
+		var deviceFilter filter.SimpleFilter
+		for _, req := range todoFilterList {
+			if !deviceFilter.UniqueFilter(req) {
+				crawler.Result.RequestList = append(crawler.Result.RequestList, req)
+			}
 		}
 	}
-	crawler.WaitGroup.Wait()
 
 	// 对全部请求进行唯一去重
 
@@ -244,10 +475,88 @@ func (crawler *Crawler) Run() {
 	crawler.Result.AllDomainList = domainCollect.AllDomainCollect(crawler.Result.AllRequestList)
 	// 子域名
 	crawler.Result.SubDomainList = domainCollect.SubDomainCollect(crawler.Result.AllRequestList, crawler.RootDomain)
+	// 响应头中的域名,包括CSP中的通配符域名
+	crawler.Result.HeaderURLList = domainCollect.UniqueHeaderURLs(crawler.Result.HeaderURLList)
+	if crawler.NearDuplicate != nil {
+		crawler.Result.NearDuplicateList = crawler.NearDuplicate.Templates()
+	}
+	if crawler.FilterReport != nil {
+		crawler.Result.FilterReport = crawler.FilterReport.Groups()
+	}
+	crawler.Result.AllDomainList, crawler.Result.SubDomainList = domainCollect.MergeHeaderDomains(crawler.Result.AllDomainList, crawler.Result.SubDomainList, crawler.Result.HeaderURLList, crawler.RootDomain)
+	// 参数清单和参数字典,tab页中的请求在过滤前已经收集,这里只收集爬取目标
+	for _, req := range crawler.Targets {
+		crawler.ParamCollect.AddRequest(req)
+	}
+	for _, form := range crawler.Result.FormList {
+		crawler.ParamCollect.AddForm(form)
+	}
+	crawler.Result.ParamList = crawler.ParamCollect.Entries()
+	crawler.Result.EndpointParamList = crawler.ParamCollect.Inventory()
+}
+
+// RecordFiltered 将被过滤的请求及原因记录到过滤报告中
+func (crawler *Crawler) RecordFiltered(req *httplib.RequestCrawler) {
+	if crawler.FilterReport != nil {
+		crawler.FilterReport.Add(req)
+	}
+}
+
+// FilterConfig 按照任务配置生成过滤器配置
+func (crawler *Crawler) FilterConfig() filter.Config {
+	return filter.Config{
+		HostLimit:     crawler.HostLimit,
+		Store:         crawler.Option.FilterStore,
+		FalsePositive: crawler.Option.FilterFalsePositive,
+		StatMaxKeys:   crawler.Option.FilterStatMaxKeys,
+	}
+}
+
+// DeepCrawlerWithDevice 在指定的设备配置下使用指定的过滤器执行深度爬虫,每个设备使用独立的过滤器,
+// 爬取计数在全部设备之间共享,先执行的设备用完最大爬取数量后,之后的设备不再打开新的tab页
+func (crawler *Crawler) DeepCrawlerWithDevice(device enums2.DeviceProfile, deviceFilter filter.Filter) {
+	crawler.Device = device
+	crawler.Filter = deviceFilter
+
+	// 执行tab任务做深度的自动化爬虫
+	var initDeepCrawler []*httplib.RequestCrawler
+	for i := 0; i < len(crawler.Targets); i++ {
+		if crawler.Filter.DoFilter(crawler.Targets[i]) {
+			crawler.RecordFiltered(crawler.Targets[i])
+			continue
+		}
+		initDeepCrawler = append(initDeepCrawler, crawler.Targets[i])
+		crawler.Result.RequestList = append(crawler.Result.RequestList, crawler.Targets[i])
+	}
+
+	// 执行更深层的tab页爬虫
+	for i := 0; i < len(initDeepCrawler); i++ {
+		if !engine2.IsIgnoredByKeywordMatch(*initDeepCrawler[i], crawler.Option.IgnoreKeywords) {
+			crawler.DeepCrawlerTaskPool(initDeepCrawler[i])
+		}
+	}
+	crawler.WaitGroup.Wait()
+	if reporter, ok := crawler.Filter.(filter.MemoryReporter); ok {
+		usage := reporter.MemoryUsage()
+		usage.Device = device.Name
+		crawler.Result.FilterMemoryList = append(crawler.Result.FilterMemoryList, &usage)
+	}
 }
 
 // DeepCrawlerTaskPool 深度的爬虫任务，主要通过tab标签页任务，来进行爬取
 func (crawler *Crawler) DeepCrawlerTaskPool(req *httplib.RequestCrawler) {
+	// 只读模式下不导航到非只读方法的请求
+	if crawler.Option.ReadOnly && !engine2.IsSafeMethod(req.Method) {
+		return
+	}
+	// 只记录不导航的请求
+	if req.NoNavigate {
+		return
+	}
+	// 地址模板下已经出现足够多几乎相同的页面,不再导航
+	if crawler.NearDuplicate != nil && crawler.NearDuplicate.Saturated(req.URL) {
+		return
+	}
 	crawler.CrawlerCountLock.Lock()
 	// 如果爬取的总数已经大于最大的爬取数量后
 	if crawler.CrawlerAlreadyCount >= crawler.Option.MaxCrawlerCount {
@@ -258,7 +567,7 @@ func (crawler *Crawler) DeepCrawlerTaskPool(req *httplib.RequestCrawler) {
 	}
 	crawler.CrawlerCountLock.Unlock()
 	crawler.WaitGroup.Add(1)
-	tabCrawler := &TabCrawler{crawler: crawler, browser: crawler.Browser, request: req}
+	tabCrawler := &TabCrawler{crawler: crawler, browser: crawler.Browser, request: req, device: crawler.Device}
 	go func() {
 		err := crawler.Pool.Submit(tabCrawler.TabCrawlerTask)
 		if err != nil {
@@ -270,8 +579,16 @@ func (crawler *Crawler) DeepCrawlerTaskPool(req *httplib.RequestCrawler) {
 // TabCrawlerTask 新建一个页面爬虫任务
 func (t *TabCrawler) TabCrawlerTask() {
 	defer t.crawler.WaitGroup.Done()
+	// 任务在协程池中排队期间地址模板可能已经饱和,开始爬取前再次检查,并归还占用的爬取数量
+	if t.crawler.NearDuplicate != nil && t.crawler.NearDuplicate.Saturated(t.request.URL) {
+		t.crawler.CrawlerCountLock.Lock()
+		t.crawler.CrawlerAlreadyCount -= 1
+		t.crawler.CrawlerCountLock.Unlock()
+		return
+	}
 	tab := engine2.NewCrawlerTab(t.browser, *t.request, engine2.TabConfig{
 		RootDomain:              t.crawler.RootDomain,
+		HostLimit:               t.crawler.HostLimit,
 		TabRunTimeout:           t.crawler.Option.TabRunTimeout,
 		DomContentLoadedTimeout: t.crawler.Option.DomContentLoadedTimeout,
 		EventTriggerMode:        t.crawler.Option.EventTriggerMode,
@@ -279,45 +596,86 @@ func (t *TabCrawler) TabCrawlerTask() {
 		BeforeExitDelay:         t.crawler.Option.BeforeExitDelay,
 		EncodeURLWithCharset:    t.crawler.Option.EncodeURLWithCharset,
 		IgnoreKeywords:          t.crawler.Option.IgnoreKeywords,
+		DangerKeywords:          t.crawler.Option.DangerKeywords,
+		ReadOnly:                t.crawler.Option.ReadOnly,
 		CustomFormValues:        t.crawler.Option.CustomFormValues,
 		CustomFormKeywordValues: t.crawler.Option.CustomFormKeywordValues,
+		BypassFormValidation:    t.crawler.Option.BypassFormValidation,
 		Custom401Auth:           t.crawler.Option.Custom401Auth,
+		ArchiveDir:              t.crawler.Option.ArchiveDir,
+		ArchiveSnapshot:         t.crawler.Option.ArchiveSnapshot,
+		ArchiveCompress:         t.crawler.Option.ArchiveCompress,
+		ArchiveMaxSize:          t.crawler.Option.ArchiveMaxSize,
+		Device:                  t.device,
+		Fingerprint:             enums2.NewFingerprint(t.device, t.crawler.Option.Languages, t.crawler.Option.Timezone),
+		UploadDir:               t.crawler.UploadDir,
+		SPAExplore:              t.crawler.Option.SPAExplore,
+		ExploreMaxActions:       t.crawler.Option.ExploreMaxActions,
+		ClientRoute:             t.crawler.Option.ClientRoute,
+		InfiniteScroll:          t.crawler.Option.InfiniteScroll,
+		ScrollMaxCount:          t.crawler.Option.ScrollMaxCount,
+		BodyStore:               t.crawler.BodyStore,
+		WebSocketMaxFrames:      t.crawler.Option.WebSocketMaxFrames,
+		CustomDefinedRegex:      t.crawler.Option.CustomDefinedRegex,
+		Proxy:                   t.crawler.Option.Proxy,
+		SourceMap:               t.crawler.Option.SourceMap,
+		SourceMapDir:            t.crawler.Option.SourceMapDir,
+		SourceMapSet:            t.crawler.SourceMapSet,
+		WorkerScriptSet:         t.crawler.WorkerScriptSet,
+		NearDuplicate:           t.crawler.NearDuplicate != nil,
 	})
-	tab.HrefClick = mapset.NewSet()         // 链接是否点击过了
-	tab.CollectLinkMapSet = mapset.NewSet() // 判断这个链接是否已经收集过了
+	tab.HrefClick = mapset.NewSet()                             // 链接是否点击过了
+	tab.CollectLinkMapSet = t.crawler.FilterConfig().NewStore() // 判断这个链接是否已经收集过了
 	// 存在结果时,会调用该回调函数
 	tab.ResultCallback = func(v *httplib.RequestCrawler) error {
-		if tab.CollectLinkMapSet.Contains(utils.CalcMD5Hash(v.URL.String())) {
+		if !tab.CollectLinkMapSet.Add(utils.CalcMD5Hash(v.URL.String())) {
 			return nil
 		}
-		tab.CollectLinkMapSet.Add(utils.CalcMD5Hash(v.URL.String()))
 		return t.crawler.ResultCallback(v)
 	}
 	tab.Start()
+	// 记录页面指纹,同一个模板下几乎相同的页面达到阈值后不再扩展该模板
+	if tab.PageFingerprint != nil {
+		t.crawler.NearDuplicate.Add(t.request.URL, *tab.PageFingerprint)
+	}
 	// 结束后,我们在进行结果列表的整合
 	t.crawler.Result.MergeResultAttachLock.Lock()
 	t.crawler.Result.AllRequestList = append(t.crawler.Result.AllRequestList, tab.ResultList...)
+	t.crawler.Result.FormList = append(t.crawler.Result.FormList, tab.FormList...)
+	t.crawler.Result.SkippedElementList = append(t.crawler.Result.SkippedElementList, tab.SkippedElementList...)
+	t.crawler.Result.SourceMapList = append(t.crawler.Result.SourceMapList, tab.SourceMapList...)
+	t.crawler.Result.HeaderURLList = append(t.crawler.Result.HeaderURLList, tab.HeaderURLList...)
+	tab.WebSocketLock.Lock()
+	t.crawler.Result.WebSocketList = append(t.crawler.Result.WebSocketList, tab.WebSocketList...)
+	tab.WebSocketLock.Unlock()
+	if tab.StateGraph != nil {
+		t.crawler.Result.StateGraphList = append(t.crawler.Result.StateGraphList, tab.StateGraph)
+	}
 	t.crawler.Result.MergeResultAttachLock.Unlock()
+	// 按照操作记录GraphQL请求
+	for _, v := range tab.ResultList {
+		if operations, ok := graphql.ParseRequest(v); ok {
+			t.crawler.AddGraphQLOperations(operations)
+		}
+	}
+	t.crawler.AddGraphQLOperations(tab.GraphQLOperationList)
+	t.crawler.ParamCollect.AddScriptParams(tab.ScriptParamList)
 
 	for _, v := range tab.ResultList {
-		if strings.ToLower(t.crawler.Option.FilterMode) == "simple" {
-			if !t.crawler.SmartFilter.SimpleFilter.DoFilter(v) {
-				t.crawler.Result.MergeResultAttachLock.Lock()
-				t.crawler.Result.RequestList = append(t.crawler.Result.RequestList, v)
-				t.crawler.Result.MergeResultAttachLock.Unlock()
-				if !engine2.IsIgnoredByKeywordMatch(*v, t.crawler.Option.IgnoreKeywords) {
-					t.crawler.DeepCrawlerTaskPool(v)
-				}
-			}
-		} else {
-			if !t.crawler.SmartFilter.DoFilter(v) {
-				t.crawler.Result.MergeResultAttachLock.Lock()
-				t.crawler.Result.RequestList = append(t.crawler.Result.RequestList, v)
-				t.crawler.Result.MergeResultAttachLock.Unlock()
-				if !engine2.IsIgnoredByKeywordMatch(*v, t.crawler.Option.IgnoreKeywords) {
-					t.crawler.DeepCrawlerTaskPool(v)
-				}
-			}
+		filtered := t.crawler.Filter.DoFilter(v)
+		// 参数清单使用去重前的请求,同一个地址上不同的参数组合都会被收集,限制域名以外的请求不收集
+		if !filtered || v.Filter.Reason != filter.ReasonDomain {
+			t.crawler.ParamCollect.AddRequest(v)
+		}
+		if filtered {
+			t.crawler.RecordFiltered(v)
+			continue
+		}
+		t.crawler.Result.MergeResultAttachLock.Lock()
+		t.crawler.Result.RequestList = append(t.crawler.Result.RequestList, v)
+		t.crawler.Result.MergeResultAttachLock.Unlock()
+		if !engine2.IsIgnoredByKeywordMatch(*v, t.crawler.Option.IgnoreKeywords) {
+			t.crawler.DeepCrawlerTaskPool(v)
 		}
 	}
 }
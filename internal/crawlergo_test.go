@@ -3,9 +3,11 @@ package internal
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/panjf2000/ants/v2"
 	"github.com/sairson/crawlergo/internal/engine/enums"
 	"github.com/sairson/crawlergo/internal/engine/httplib"
 	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"github.com/sairson/crawlergo/internal/filter"
 	"github.com/sairson/crawlergo/internal/option"
 	"testing"
 )
@@ -80,3 +82,58 @@ func getOption(taskOptions option.TaskOptions, postData string) httplib.OptionsC
 	}
 	return options
 }
+
+func TestTabCrawlerTask_SaturatedWhileQueued(t *testing.T) {
+	pool, _ := ants.NewPool(1)
+	defer pool.Release()
+	crawler := &Crawler{
+		Option:        &option.TaskOptions{MaxCrawlerCount: 10},
+		NearDuplicate: filter.NewNearDuplicate(2, 4),
+		Pool:          pool,
+	}
+	// 占用唯一的协程,让后面的任务在协程池中排队
+	release := make(chan struct{})
+	started := make(chan struct{})
+	_ = pool.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	u, _ := urllib.GetURL("http://testphp.vulnweb.com/product.php?pic=3")
+	crawler.DeepCrawlerTaskPool(httplib.GetCrawlerRequest(enums.GET, u))
+	if crawler.CrawlerAlreadyCount != 1 {
+		t.Fatalf("expected task to be queued, got count %d", crawler.CrawlerAlreadyCount)
+	}
+	// 排队期间同一个模板下出现足够多几乎相同的页面
+	fingerprint := filter.FingerprintHTML("<html><body><h1>product</h1><p>not found</p></body></html>")
+	for _, raw := range []string{"http://testphp.vulnweb.com/product.php?pic=1", "http://testphp.vulnweb.com/product.php?pic=2"} {
+		pu, _ := urllib.GetURL(raw)
+		crawler.NearDuplicate.Add(pu, fingerprint)
+	}
+	if !crawler.NearDuplicate.Saturated(u) {
+		t.Fatal("expected template to be saturated")
+	}
+	// 浏览器为空,任务没有提前返回时会直接panic
+	close(release)
+	crawler.WaitGroup.Wait()
+	if crawler.CrawlerAlreadyCount != 0 {
+		t.Fatalf("expected crawl count to be returned, got %d", crawler.CrawlerAlreadyCount)
+	}
+}
+
+func TestDeepCrawlerWithDevice_SharedCrawlCount(t *testing.T) {
+	crawler := &Crawler{Option: &option.TaskOptions{MaxCrawlerCount: 1}}
+	u, _ := urllib.GetURL("http://testphp.vulnweb.com/")
+	crawler.Targets = []*httplib.RequestCrawler{httplib.GetCrawlerRequest(enums.GET, u)}
+	// 第一个设备已经用完了最大爬取数量
+	crawler.CrawlerAlreadyCount = 1
+	f, err := filter.New(filter.ModeSimple, filter.Config{HostLimit: u.Host})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 浏览器和协程池为空,第二个设备重新开始计数时会提交任务并panic
+	crawler.DeepCrawlerWithDevice(enums.DefaultDeviceProfiles["iphone"], f)
+	if crawler.CrawlerAlreadyCount != 1 {
+		t.Fatalf("expected crawl count shared across devices, got %d", crawler.CrawlerAlreadyCount)
+	}
+}
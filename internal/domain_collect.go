@@ -2,6 +2,7 @@ package internal
 
 import (
 	mapset "github.com/deckarep/golang-set"
+	engine2 "github.com/sairson/crawlergo/internal/engine"
 	"github.com/sairson/crawlergo/internal/engine/httplib"
 	"strings"
 )
@@ -38,3 +39,38 @@ func (domain *DomainCollect) SubDomainCollect(reqList []*httplib.RequestCrawler,
 	}
 	return subDomainList
 }
+
+// UniqueHeaderURLs 响应头中的地址和域名去重,相同响应头的同一个指令中的相同地址只保留一个
+func (domain *DomainCollect) UniqueHeaderURLs(list []*engine2.HeaderURL) []*engine2.HeaderURL {
+	uniqueSet := mapset.NewSet()
+	var uniqueList []*engine2.HeaderURL
+	for _, item := range list {
+		key := strings.ToLower(item.Header) + "|" + item.Directive + "|" + item.URL + "|" + item.Domain
+		if uniqueSet.Contains(key) {
+			continue
+		}
+		uniqueSet.Add(key)
+		uniqueList = append(uniqueList, item)
+	}
+	return uniqueList
+}
+
+// MergeHeaderDomains 将响应头中的域名合并到全部域名和子域名列表中
+func (domain *DomainCollect) MergeHeaderDomains(allDomainList []string, subDomainList []string, list []*engine2.HeaderURL, HostLimit string) ([]string, []string) {
+	uniqueSet := mapset.NewSet()
+	for _, d := range allDomainList {
+		uniqueSet.Add(d)
+	}
+	for _, item := range list {
+		d := item.Domain
+		if d == "" || uniqueSet.Contains(d) {
+			continue
+		}
+		uniqueSet.Add(d)
+		allDomainList = append(allDomainList, d)
+		if strings.HasSuffix(d, "."+HostLimit) {
+			subDomainList = append(subDomainList, d)
+		}
+	}
+	return allDomainList, subDomainList
+}
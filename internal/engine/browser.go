@@ -21,7 +21,7 @@ type Tabs struct {
 	TabCancel  *context.CancelFunc
 }
 
-func InitBrowser(chromium string, extraHeaders map[string]interface{}, proxy string, noHeadless bool) (*Browser, error) {
+func InitBrowser(chromium string, extraHeaders map[string]interface{}, proxy string, noHeadless bool, disableSiteIsolation bool) (*Browser, error) {
 	var browser = &Browser{}
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		// 是否启用无头模式
@@ -49,6 +49,13 @@ func InitBrowser(chromium string, extraHeaders map[string]interface{}, proxy str
 
 		chromedp.WindowSize(1920, 1080),
 	)
+	if disableSiteIsolation {
+		// 关闭站点隔离,跨域iframe和顶层页面在同一个渲染进程中,可以通过执行上下文访问
+		opts = append(opts,
+			chromedp.Flag("disable-site-isolation-trials", true),
+			chromedp.Flag("disable-features", "site-per-process,IsolateOrigins,Translate,BlinkGenPropertyTrees"),
+		)
+	}
 	if proxy != "" {
 		opts = append(opts, chromedp.ProxyServer(proxy))
 	}
@@ -3,7 +3,6 @@ package engine
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
@@ -14,6 +13,11 @@ import (
 	"github.com/gogf/gf/encoding/gcharset"
 	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
 	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/filter"
+	"github.com/sairson/crawlergo/internal/graphql"
+	"github.com/sairson/crawlergo/internal/sourcemap"
+	"github.com/sairson/crawlergo/internal/store"
+	"log"
 	"regexp"
 	"strings"
 	"sync"
@@ -27,6 +31,16 @@ type Tab struct {
 	NavigateRequest              httplib.RequestCrawler    // 活跃的请求信息
 	ExtraHeaders                 map[string]interface{}    // 额外的请求头
 	ResultList                   []*httplib.RequestCrawler // tab爬虫结果列表
+	FormList                     []*httplib.Form           // tab页中解析出来的表单
+	StateGraph                   *StateGraph               // 状态探索得到的状态图
+	ActionPath                   []httplib.Action          // 状态探索时当前正在执行的动作路径
+	ClientRouteSet               mapset.Set                // 脚本中解析出来的前端路由
+	SkippedElementList           []*SkippedElement         // 匹配危险关键字而跳过的元素
+	GraphQLOperationList         []*graphql.Operation      // 脚本中解析出来的GraphQL操作
+	SourceMapList                []*sourcemap.Record       // 还原的source map
+	ScriptParamList              []string                  // 脚本中对象字面量的属性名,每个脚本内去重
+	HeaderURLList                []*HeaderURL              // 响应头中解析出来的地址和域名
+	PageFingerprint              *filter.PageFingerprint   // 渲染后页面的指纹,开启相似页面检测时计算
 	ResultCallback               func(v *httplib.RequestCrawler) error
 	CustomDefinedRegexResultList []struct {
 		Regexp string
@@ -41,27 +55,40 @@ type Tab struct {
 	DocBodyNodeId    cdp.NodeID
 	Lock             sync.Mutex
 	config           TabConfig
+	FrameContexts    map[runtime.ExecutionContextID]string // 每个frame的默认执行上下文
+	FrameContextLock sync.Mutex
 
-	WaitGroup            sync.WaitGroup // 当前Tab页的等待同步计数
-	CollectLinkWaitGroup sync.WaitGroup // 收集链接等待计数
-	LoadedWaitGroup      sync.WaitGroup // Loaded之后的等待计数
-	FormSubmitWaitGroup  sync.WaitGroup // 表单提交完毕的等待计数
-	RemoveList           sync.WaitGroup // 移除事件监听
-	DomWaitGroup         sync.WaitGroup // DOMContentLoaded 的等待计数
-	FillFormWaitGroup    sync.WaitGroup // 填充表单任务
-	HrefClick            mapset.Set     // 链接点击去重
-	CollectLinkMapSet    mapset.Set     // 收集结果去重
+	NetworkRequests    map[string]*httplib.RequestCrawler // network的RequestID对应的请求
+	ResponseInfos      map[string]*httplib.ResponseInfo   // network的RequestID对应的响应信息
+	ResponseStartTimes map[string]float64                 // 请求开始的时间,用于计算请求的耗时
+	ResponseLock       sync.Mutex
+
+	WebSockets    map[string]*WebSocketRecord // network的RequestID对应的WebSocket连接
+	WebSocketList []*WebSocketRecord          // 按照创建顺序排列的WebSocket连接
+	WebSocketLock sync.Mutex
+
+	WaitGroup            sync.WaitGroup     // 当前Tab页的等待同步计数
+	CollectLinkWaitGroup sync.WaitGroup     // 收集链接等待计数
+	LoadedWaitGroup      sync.WaitGroup     // Loaded之后的等待计数
+	FormSubmitWaitGroup  sync.WaitGroup     // 表单提交完毕的等待计数
+	RemoveList           sync.WaitGroup     // 移除事件监听
+	DomWaitGroup         sync.WaitGroup     // DOMContentLoaded 的等待计数
+	FillFormWaitGroup    sync.WaitGroup     // 填充表单任务
+	HrefClick            mapset.Set         // 链接点击去重
+	CollectLinkMapSet    filter.UniqueStore // 收集结果去重
 }
 
 // TabConfig 每一个页面的配置信息
 type TabConfig struct {
 	TabRunTimeout           time.Duration
 	DomContentLoadedTimeout time.Duration
-	EventTriggerMode        string        // 事件触发的调用方式： 异步 或 顺序
+	EventTriggerMode        string        // 事件触发的调用方式： 异步、顺序 或 可信事件
 	EventTriggerInterval    time.Duration // 事件触发的间隔 单位毫秒
 	BeforeExitDelay         time.Duration // 退出前的等待时间，等待DOM渲染，等待XHR发出捕获
 	EncodeURLWithCharset    bool
 	IgnoreKeywords          []string // 忽略的关键字
+	DangerKeywords          []string // 危险操作关键字,匹配上的元素不会被点击或提交
+	ReadOnly                bool     // 只读模式,阻止GET、HEAD、OPTIONS之外的请求
 	IgnoreStatic            bool     // 是否忽略静态资源文件
 	CustomDefinedRegex      []string // 用户自定义正则,这个正则会在获取到js,css,json等文件被执行
 	Custom401Auth           struct {
@@ -71,7 +98,28 @@ type TabConfig struct {
 	Proxy                   string
 	CustomFormValues        map[string]string
 	CustomFormKeywordValues map[string]string
+	BypassFormValidation    bool // 是否关闭表单校验后提交
 	RootDomain              string
+	HostLimit               string               // 爬取限制的域名,直接发送的请求只对该域名和根域名携带Cookie
+	ArchiveDir              string               // 渲染后DOM的归档目录
+	ArchiveSnapshot         bool                 // 是否归档DOMSnapshot
+	ArchiveCompress         bool                 // 是否gzip压缩归档文件
+	ArchiveMaxSize          int                  // 单个归档文件的最大字节数
+	Device                  enums2.DeviceProfile // 设备模拟配置
+	Fingerprint             enums2.Fingerprint   // 浏览器指纹配置
+	UploadDir               *UploadDir           // 文件上传样例文件目录,为空时不上传文件
+	SPAExplore              bool                 // 是否开启单页应用状态探索
+	ExploreMaxActions       int                  // 状态探索最多执行的动作数
+	ClientRoute             bool                 // 是否解析并访问前端路由
+	InfiniteScroll          bool                 // 是否触发无限滚动和懒加载
+	ScrollMaxCount          int                  // 无限滚动最多滚动的次数
+	BodyStore               *store.BodyStore     // 响应体存储,为空时不存储
+	WebSocketMaxFrames      int                  // 每个WebSocket连接最多记录的消息帧数
+	SourceMap               bool                 // 是否获取source map并还原源码
+	SourceMapDir            string               // 还原源码的保存目录,为空时不保存
+	SourceMapSet            mapset.Set           // source map去重,所有页面共享
+	WorkerScriptSet         mapset.Set           // worker脚本去重,所有页面共享
+	NearDuplicate           bool                 // 是否计算渲染后页面的指纹
 }
 
 type BindingCallPayload struct {
@@ -96,6 +144,12 @@ func NewCrawlerTab(browser *Browser, navigateRequest httplib.RequestCrawler, con
 	tab.NavigateRequest = navigateRequest
 	tab.config = config
 	tab.DocBodyNodeId = 0
+	tab.ClientRouteSet = mapset.NewSet()
+	tab.FrameContexts = make(map[runtime.ExecutionContextID]string)
+	tab.NetworkRequests = make(map[string]*httplib.RequestCrawler)
+	tab.ResponseInfos = make(map[string]*httplib.ResponseInfo)
+	tab.ResponseStartTimes = make(map[string]float64)
+	tab.WebSockets = make(map[string]*WebSocketRecord)
 	// tab页初始配置完成,我们设置chromedp的监听tab页的上下文
 	chromedp.ListenTarget(*tab.Context, func(ev interface{}) {
 		switch v := ev.(type) {
@@ -109,8 +163,9 @@ func NewCrawlerTab(browser *Browser, navigateRequest httplib.RequestCrawler, con
 			go tab.InterceptTabRequest(v)
 
 		case *network.EventResponseReceived: // 当请求被接收的时候
+			tab.HandleResponseReceived(v)
 			// 我们需要解析全部的JS文件并找到请求,此时我们也可以匹配一些正则来获取密钥结果,当然还有css文件,当中也有可能有一些相关的url链接
-			if strings.Contains(strings.ToLower(v.Response.MimeType), "text/css") || strings.Contains(strings.ToLower(v.Response.MimeType), "application/javascript") || strings.Contains(strings.ToLower(v.Response.MimeType), "text/html") || strings.ToLower(v.Response.MimeType) == "application/json" {
+			if IsParsedResponseMime(v.Response.MimeType) {
 				tab.WaitGroup.Add(1)
 				go tab.ParseRequestURLFormResponseText(v)
 			}
@@ -118,11 +173,25 @@ func NewCrawlerTab(browser *Browser, navigateRequest httplib.RequestCrawler, con
 				tab.WaitGroup.Add(1)
 				go tab.GetContentCharset(v)
 			}
-			// 这里我们不单单要解析全部的JS文件,还要从响应头中获取一些相关信息
-			if v.RequestID.String() == tab.NavNetworkID {
-				tab.WaitGroup.Add(1)
-				go tab.ParseRequestURLFromResponseHeader(v)
-			}
+			// 这里我们不单单要解析全部的JS文件,还要从响应头中获取一些相关信息,CORS等响应头主要出现在接口的响应中,因此解析全部响应
+			tab.WaitGroup.Add(1)
+			go tab.ParseRequestURLFromResponseHeader(v)
+		case *network.EventDataReceived: // 接收到响应体数据
+			tab.HandleDataReceived(v)
+		case *network.EventLoadingFinished: // 响应接收完成
+			tab.HandleLoadingFinished(v)
+		case *network.EventWebSocketCreated: // WebSocket连接
+			tab.HandleWebSocketCreated(v)
+		case *network.EventWebSocketWillSendHandshakeRequest:
+			tab.HandleWebSocketHandshakeRequest(v)
+		case *network.EventWebSocketHandshakeResponseReceived:
+			tab.HandleWebSocketHandshakeResponse(v)
+		case *network.EventWebSocketFrameSent:
+			tab.HandleWebSocketFrame(v.RequestID, WebSocketSent, v.Response)
+		case *network.EventWebSocketFrameReceived:
+			tab.HandleWebSocketFrame(v.RequestID, WebSocketReceived, v.Response)
+		case *network.EventWebSocketClosed:
+			tab.HandleWebSocketClosed(v)
 		case *network.EventResponseReceivedExtraInfo: // 后端重定向请求
 			if v.RequestID.String() == tab.NavNetworkID {
 				tab.WaitGroup.Add(1)
@@ -150,6 +219,12 @@ func NewCrawlerTab(browser *Browser, navigateRequest httplib.RequestCrawler, con
 		case *page.EventJavascriptDialogOpening:
 			tab.WaitGroup.Add(1)
 			go tab.DismissDialog()
+		case *runtime.EventExecutionContextCreated: // 记录frame的执行上下文
+			tab.HandleExecutionContextCreated(v)
+		case *runtime.EventExecutionContextDestroyed:
+			tab.HandleExecutionContextDestroyed(v.ExecutionContextID)
+		case *runtime.EventExecutionContextsCleared:
+			tab.ClearExecutionContexts()
 		case *runtime.EventBindingCalled: // 控制暴漏的函数
 			tab.WaitGroup.Add(1)
 			go tab.HandleBindingCalled(v)
@@ -182,6 +257,10 @@ func (tab *Tab) Start() {
 			// XSS-Scan 使用的回调
 			runtime.AddBinding("addLink"),
 			runtime.AddBinding("Test"),
+			// 设备和视口模拟
+			tab.EmulateDevice(),
+			// 浏览器指纹模拟
+			tab.EmulateFingerprint(),
 			// 初始化执行JS
 			chromedp.ActionFunc(func(ctx context.Context) error {
 				var err error
@@ -211,11 +290,40 @@ func (tab *Tab) Start() {
 	case <-waitDone():
 	case <-time.After(tab.config.DomContentLoadedTimeout + time.Second*10):
 	}
+	// 解析web app manifest
+	tab.CollectAppManifest()
+	// 单页应用状态探索
+	if tab.config.SPAExplore {
+		tab.ExploreStates()
+		select {
+		case <-waitDone():
+		case <-time.After(tab.config.DomContentLoadedTimeout):
+		}
+	}
+	// 访问前端路由
+	if tab.config.ClientRoute {
+		tab.VisitClientRoutes()
+		select {
+		case <-waitDone():
+		case <-time.After(tab.config.DomContentLoadedTimeout):
+		}
+	}
 	// 等待收集全部的链接
 	tab.CollectLinkWaitGroup.Add(3)
 	go tab.CollectTabLinks() //收集全部的链接
 	tab.CollectLinkWaitGroup.Wait()
 
+	// 归档渲染后的DOM
+	if tab.config.ArchiveDir != "" {
+		if err := tab.ArchiveRenderedDOM(); err != nil {
+			log.Printf("archive rendered dom of %s failed: %v", tab.NavigateRequest.URL.String(), err)
+		}
+	}
+	// 计算页面指纹
+	if tab.config.NearDuplicate {
+		tab.CollectPageFingerprint()
+	}
+
 	// 识别页面编码 并编码所有URL
 	if tab.config.EncodeURLWithCharset {
 		tab.DetectCharset()
@@ -242,16 +350,12 @@ func (tab *Tab) CollectTabLinks() {
 // CollectAttributeLinksFromTab 收集全部的href链接
 func (tab *Tab) CollectAttributeLinksFromTab() {
 	defer tab.CollectLinkWaitGroup.Done()
-	ctx := tab.GetCDPExecutor()
 	// 收集 src href data-url action data等属性值属性值(有些是生成chat gpt生成完善的)
 	attrNameList := []string{"src", "href", "link", "data-url", "codebase", "data-href", "action", "dynsrc", "image-href", "script-href", "data", "poster", "manifest", "ping", "longdesc", "usemap", "background", "source", "formaction"}
 	for _, attrName := range attrNameList {
-		tCtx, cancel := context.WithTimeout(ctx, time.Second*1)
-		var attrs []map[string]string
-		_ = chromedp.AttributesAll(fmt.Sprintf(`[%s]`, attrName), &attrs, chromedp.ByQueryAll).Do(tCtx)
-		cancel()
-		for _, attrMap := range attrs {
-			tab.AddResultFormCustomUrl(enums2.GET, attrMap[attrName], enums2.FromDOM)
+		// 包含iframe和shadow root中的元素,相对地址按照元素所在的文档解析
+		for _, value := range tab.GetAttributeValues(attrName, true) {
+			tab.AddResultFormCustomUrl(enums2.GET, value, enums2.FromDOM)
 		}
 	}
 }
@@ -259,14 +363,13 @@ func (tab *Tab) CollectAttributeLinksFromTab() {
 // CollectObjectLinksFormTab 收集对象中的链接
 func (tab *Tab) CollectObjectLinksFormTab() {
 	defer tab.CollectLinkWaitGroup.Done()
-	ctx := tab.GetCDPExecutor()
 	// 收集 object[data] links
-	tCtx, cancel := context.WithTimeout(ctx, time.Second*1)
-	defer cancel()
-	var attrs []map[string]string
-	_ = chromedp.AttributesAll(`object[data]`, &attrs, chromedp.ByQueryAll).Do(tCtx)
-	for _, attrMap := range attrs {
-		tab.AddResultFormCustomUrl(enums2.GET, attrMap["data"], enums2.FromDOM)
+	nodes, err := tab.GetNodes(`object[data]`)
+	if err != nil {
+		return
+	}
+	for _, node := range nodes {
+		tab.AddResultFormCustomUrl(enums2.GET, node.AttributeValue("data"), enums2.FromDOM)
 	}
 }
 
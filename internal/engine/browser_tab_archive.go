@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/chromedp/cdproto/domsnapshot"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 这里处理渲染后DOM的归档,方便离线检索渲染后的页面
+
+// ArchiveRecord 归档索引中的一条记录
+type ArchiveRecord struct {
+	URL          string `json:"url"`
+	Method       string `json:"method"`
+	Device       string `json:"device,omitempty"`
+	Source       string `json:"source"`
+	HTMLFile     string `json:"html_file"`
+	HTMLSize     int    `json:"html_size"`
+	Truncated    bool   `json:"truncated"`
+	SnapshotFile string `json:"snapshot_file,omitempty"`
+	Time         string `json:"time"`
+}
+
+// archiveIndexLock 多个tab页同时写入索引文件时的锁
+var archiveIndexLock sync.Mutex
+
+// archiveNameRegex 归档文件名中不允许出现的字符
+var archiveNameRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// ArchiveRenderedDOM 归档当前tab页渲染后的outer HTML,以及可选的DOMSnapshot
+func (tab *Tab) ArchiveRenderedDOM() error {
+	if err := os.MkdirAll(tab.config.ArchiveDir, 0755); err != nil {
+		return err
+	}
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	html, err := tab.renderedOuterHTML(tCtx)
+	if err != nil {
+		return err
+	}
+	name := archiveFileName(tab.config.Device.Name, tab.NavigateRequest.NoHeaderId())
+	record := ArchiveRecord{
+		URL:      tab.NavigateRequest.URL.String(),
+		Method:   tab.NavigateRequest.Method,
+		Device:   tab.config.Device.Name,
+		Source:   tab.NavigateRequest.Source,
+		HTMLSize: len(html),
+		Time:     time.Now().Format(time.RFC3339),
+	}
+	// HTML超过大小限制时截断
+	if tab.config.ArchiveMaxSize > 0 && len(html) > tab.config.ArchiveMaxSize {
+		html = truncateUTF8(html, tab.config.ArchiveMaxSize)
+		record.Truncated = true
+	}
+	record.HTMLFile, err = tab.writeArchiveFile(name+".html", []byte(html))
+	if err != nil {
+		return err
+	}
+	// 归档完整的DOMSnapshot,超过大小限制时丢弃,截断后的json没有意义
+	if tab.config.ArchiveSnapshot {
+		documents, strs, err := domsnapshot.CaptureSnapshot([]string{}).Do(tCtx)
+		if err == nil {
+			snapshot, _ := json.Marshal(map[string]interface{}{
+				"documents": documents,
+				"strings":   strs,
+			})
+			if tab.config.ArchiveMaxSize <= 0 || len(snapshot) <= tab.config.ArchiveMaxSize {
+				record.SnapshotFile, _ = tab.writeArchiveFile(name+".snapshot.json", snapshot)
+			}
+		}
+	}
+	return tab.appendArchiveIndex(record)
+}
+
+// archiveFileName 归档文件名加上设备配置的名称,多设备爬取同一个页面时不会互相覆盖
+func archiveFileName(device string, id string) string {
+	device = archiveNameRegex.ReplaceAllString(device, "_")
+	if device == "" {
+		return id
+	}
+	return device + "_" + id
+}
+
+// truncateUTF8 截断字符串到不超过max字节,截断位置回退到字符边界,避免产生不完整的UTF-8字符
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// writeArchiveFile 写入归档文件,开启压缩时追加.gz后缀,返回相对于归档目录的文件名
+func (tab *Tab) writeArchiveFile(name string, data []byte) (string, error) {
+	if tab.config.ArchiveCompress {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(data); err != nil {
+			return "", err
+		}
+		if err := gz.Close(); err != nil {
+			return "", err
+		}
+		name += ".gz"
+		data = buf.Bytes()
+	}
+	return name, os.WriteFile(filepath.Join(tab.config.ArchiveDir, name), data, 0644)
+}
+
+// appendArchiveIndex 追加一条归档记录到索引文件
+func (tab *Tab) appendArchiveIndex(record ArchiveRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	archiveIndexLock.Lock()
+	defer archiveIndexLock.Unlock()
+	f, err := os.OpenFile(filepath.Join(tab.config.ArchiveDir, enums2.ArchiveIndexFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	for _, c := range []struct {
+		s        string
+		max      int
+		expected string
+	}{
+		{"abcdef", 10, "abcdef"},
+		{"abcdef", 3, "abc"},
+		{"中文abc", 4, "中"},
+		{"中文abc", 6, "中文"},
+		{"中文abc", 2, ""},
+	} {
+		if got := truncateUTF8(c.s, c.max); got != c.expected {
+			t.Fatalf("truncateUTF8(%q, %d): expected %q, got %q", c.s, c.max, c.expected, got)
+		}
+	}
+}
+
+func TestArchiveFileName(t *testing.T) {
+	if name := archiveFileName("", "abc"); name != "abc" {
+		t.Fatalf("expected abc, got %s", name)
+	}
+	if name := archiveFileName("iPhone 12/Pro", "abc"); name != "iPhone_12_Pro_abc" {
+		t.Fatalf("expected iPhone_12_Pro_abc, got %s", name)
+	}
+}
+
+func TestWriteArchiveFile(t *testing.T) {
+	dir := t.TempDir()
+	tab := &Tab{config: TabConfig{ArchiveDir: dir}}
+	name, err := tab.writeArchiveFile("page.html", []byte("<html></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil || name != "page.html" || string(content) != "<html></html>" {
+		t.Fatalf("unexpected archive file %s: %q %v", name, content, err)
+	}
+
+	tab.config.ArchiveCompress = true
+	name, err = tab.writeArchiveFile("page.html", []byte("<html></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "page.html.gz" {
+		t.Fatalf("expected page.html.gz, got %s", name)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, name))
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ = io.ReadAll(gz); string(content) != "<html></html>" {
+		t.Fatalf("unexpected decompressed content %q", content)
+	}
+}
+
+func TestAppendArchiveIndex(t *testing.T) {
+	dir := t.TempDir()
+	tab := &Tab{config: TabConfig{ArchiveDir: dir}}
+	records := []ArchiveRecord{
+		{URL: "https://example.com/", Method: "GET", Device: "desktop", HTMLFile: "desktop_a.html"},
+		{URL: "https://example.com/", Method: "GET", Device: "mobile", HTMLFile: "mobile_a.html", Truncated: true},
+	}
+	for _, record := range records {
+		if err := tab.appendArchiveIndex(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(dir, enums2.ArchiveIndexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != len(records) {
+		t.Fatalf("expected %d lines, got %d", len(records), len(lines))
+	}
+	for i, line := range lines {
+		var record ArchiveRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record != records[i] {
+			t.Fatalf("line %d: expected %+v, got %+v", i, records[i], record)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
@@ -10,7 +11,6 @@ import (
 	"github.com/chromedp/chromedp"
 	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
 	"github.com/sairson/crawlergo/pkg/utils"
-	"os"
 	"strings"
 	"time"
 )
@@ -36,6 +36,7 @@ func (tab *Tab) AfterDOMLoadedToRunClickAndJavaScript() {
 	go tab.AutoFillFormComponent() // 自动填写表单组件
 	go tab.SetObserverJS()         // 添加监听守卫js
 	tab.DomWaitGroup.Wait()
+	tab.CollectForms() // 表单填写完成后提取结构化表单
 	tab.WaitGroup.Add(1)
 	go tab.AfterLoadedToRunJavaScript() // 我们等待守卫js加载完成和表单填写完成后触发相关事件
 }
@@ -47,8 +48,10 @@ func (tab *Tab) AfterLoadedToRunJavaScript() {
 	tab.LoadedWaitGroup.Add(3)     // 加载javascript脚本组件
 	tab.RemoveList.Add(1)          // 加载监听组件
 
+	tab.GuardDangerousElements()   // 标记危险的按钮、链接和表单
 	go tab.TryToSubmitForm()       // 尝试触发全部的表单提交按钮
 	tab.FormSubmitWaitGroup.Wait() // 等待触发完成
+	tab.GuardDangerousElements()   // 表单提交后可能出现新的元素,触发事件前再检查一次
 
 	// 如果配置中触发方式是异步的
 	if tab.config.EventTriggerMode == enums2.EventTriggerAsync {
@@ -57,6 +60,13 @@ func (tab *Tab) AfterLoadedToRunJavaScript() {
 		go tab.TriggerInlineEvents()
 		go tab.TriggerDom2Events()
 		tab.LoadedWaitGroup.Wait()
+	} else if tab.config.EventTriggerMode == enums2.EventTriggerTrusted {
+		// 可信事件需要真实移动鼠标和键盘焦点,只能顺序触发
+		tab.TriggerTrustedInlineEvents()
+		time.Sleep(tab.config.EventTriggerInterval)
+		tab.TriggerTrustedDom2Events()
+		time.Sleep(tab.config.EventTriggerInterval)
+		tab.TriggerJavascriptProtocol()
 	} else {
 		// 我们均按照同步方式触发
 		tab.TriggerInlineEvents()
@@ -65,6 +75,10 @@ func (tab *Tab) AfterLoadedToRunJavaScript() {
 		time.Sleep(tab.config.EventTriggerInterval)
 		tab.TriggerJavascriptProtocol()
 	}
+	// 滚动页面触发无限滚动和懒加载的内容
+	if tab.config.InfiniteScroll {
+		tab.TriggerInfiniteScroll()
+	}
 	// 我们需要等待一段事件使得全部的事件触发后让浏览器发出相关请求
 	time.Sleep(tab.config.BeforeExitDelay)
 
@@ -76,6 +90,10 @@ func (tab *Tab) AfterLoadedToRunJavaScript() {
 // TryToSubmitForm 我们尝试提交全部的表单
 func (tab *Tab) TryToSubmitForm() {
 	tab.SetFormToFrame() // 设置表单的target
+	// 开启后清除自定义校验错误并关闭浏览器表单校验,默认按照页面的校验规则提交
+	if tab.config.BypassFormValidation {
+		tab.EvaluateInAllFrames(enums2.DisableValidationJS)
+	}
 
 	// 接下来尝试全部的提交方法
 	go tab.ClickSubmitComponent() // 尝试点击submit组件
@@ -158,27 +176,25 @@ func (f *FillForm) FillTextarea() error {
 		}
 		return nil
 	}
-	_ = chromedp.SendKeys(textareaNodes, value, chromedp.ByNodeID).Do(tCtx)
+	nodes, err := f.Tab.GetNodes(`textarea`)
+	if err != nil {
+		_ = chromedp.SendKeys(textareaNodes, value, chromedp.ByNodeID).Do(tCtx)
+		return nil
+	}
+	// 按照每个文本域的minlength、maxlength调整填充的长度
+	for _, node := range nodes {
+		attrs := nodeConstraintAttributes(node.AttributeValue)
+		_ = chromedp.SendKeys([]cdp.NodeID{node.NodeID}, fitLength(value, attrs), chromedp.ByNodeID).Do(tCtx)
+	}
 	return nil
 }
 
 // FillFormInput 填写表单的input组件
 func (f *FillForm) FillFormInput() error {
 	defer f.Tab.FillFormWaitGroup.Done()
-	var nodes []*cdp.Node
 	ctx := f.Tab.GetCDPExecutor()
-	tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
-	// 首先搜索input标签是否存在,如果不存在或存在错误直接退出
-	inputNodes, inputErr := f.Tab.GetNodeIDs(`input`)
-	if inputErr != nil || len(inputNodes) == 0 {
-		if inputErr != nil {
-			return inputErr
-		}
-		return nil
-	}
-	// 获取所有的input标签
-	err := chromedp.Nodes(`input`, &nodes, chromedp.ByQueryAll).Do(tCtx)
+	// 获取所有的input标签,包含iframe和shadow root中的,如果不存在或存在错误直接退出
+	nodes, err := f.Tab.GetNodes(`input`)
 	if err != nil {
 		return err
 	}
@@ -187,48 +203,53 @@ func (f *FillForm) FillFormInput() error {
 		// 我们通过switch-case来确定我们的标签类型
 		tCtxN, cancelN := context.WithTimeout(ctx, time.Second*5)
 		attrType := node.AttributeValue("type")
+		// 获取输入框上的约束属性,生成的值需要通过浏览器的表单校验
+		attrs := nodeConstraintAttributes(node.AttributeValue)
 		switch {
 		case strings.Contains(attrType, "text"): // 这里判断如果input标签属性是text
 			inputName := node.AttributeValue("id") + node.AttributeValue("class") + node.AttributeValue("name")
-			value := f.GetMatchInputText(inputName)
-			var nodeIds = []cdp.NodeID{node.NodeID}
-			// 先使用模拟输入
-			_ = chromedp.SendKeys(nodeIds, value, chromedp.ByNodeID).Do(tCtxN)
-			// 再直接赋值JS属性
-			_ = chromedp.SetAttributeValue(nodeIds, "value", value, chromedp.ByNodeID).Do(tCtxN)
+			value := f.GetConstraintInputText(attrs, inputName)
+			f.SetInputNodeValue(tCtxN, node.NodeID, value)
 		case strings.Contains(attrType, "email") || strings.Contains(attrType, "password") || strings.Contains(attrType, "tel"):
-			value := f.GetMatchInputText(attrType)
-			var nodeIds = []cdp.NodeID{node.NodeID}
-			// 先使用模拟输入
-			_ = chromedp.SendKeys(nodeIds, value, chromedp.ByNodeID).Do(tCtxN)
-			// 再直接赋值JS属性
-			_ = chromedp.SetAttributeValue(nodeIds, "value", value, chromedp.ByNodeID).Do(tCtxN)
+			value := f.GetConstraintInputText(attrs, attrType)
+			f.SetInputNodeValue(tCtxN, node.NodeID, value)
 		case strings.Contains(attrType, "radio") || strings.Contains(attrType, "checkbox"):
 			var nodeIds = []cdp.NodeID{node.NodeID}
 			// 为每一个检查框都设置为true属性
 			_ = chromedp.SetAttributeValue(nodeIds, "checked", "true", chromedp.ByNodeID).Do(tCtxN)
-		case strings.Contains(attrType, "file") || strings.Contains(attrType, "image"):
-			var nodeIds = []cdp.NodeID{node.NodeID}
-			wd, _ := os.Getwd()
-			filePath := wd + "/upload/image.png"
-			_ = chromedp.RemoveAttribute(nodeIds, "accept", chromedp.ByNodeID).Do(tCtxN)
-			_ = chromedp.RemoveAttribute(nodeIds, "required", chromedp.ByNodeID).Do(tCtxN)
-			_ = chromedp.SendKeys(nodeIds, filePath, chromedp.ByNodeID).Do(tCtxN)
+		case strings.Contains(attrType, "file"):
+			// 按照accept属性生成样例文件,再直接设置到文件输入框中
+			if f.Tab.config.UploadDir == nil {
+				break
+			}
+			filePath, err := f.Tab.config.UploadDir.SampleFile(node.AttributeValue("accept"))
+			if err != nil {
+				break
+			}
+			_ = dom.SetFileInputFiles([]string{filePath}).WithNodeID(node.NodeID).Do(tCtxN)
+		case attrType == "image" || attrType == "hidden" || attrType == "submit" || attrType == "button" || attrType == "reset":
+			// 按钮和隐藏域不需要填充,隐藏域中一般是csrf token之类的值,覆盖后表单反而无法提交
 		default:
-			// 其他的我们都按照text文本解析
+			// 其他的我们都按照text文本解析,number、date等类型按照约束生成
 			inputName := node.AttributeValue("id") + node.AttributeValue("class") + node.AttributeValue("name")
-			value := f.GetMatchInputText(inputName)
-			var nodeIds = []cdp.NodeID{node.NodeID}
-			// 先使用模拟输入
-			_ = chromedp.SendKeys(nodeIds, value, chromedp.ByNodeID).Do(tCtxN)
-			// 再直接赋值JS属性
-			_ = chromedp.SetAttributeValue(nodeIds, "value", value, chromedp.ByNodeID).Do(tCtxN)
+			value := f.GetConstraintInputText(attrs, inputName)
+			f.SetInputNodeValue(tCtxN, node.NodeID, value)
 		}
 		cancelN()
 	}
 	return nil
 }
 
+// SetInputNodeValue 设置输入框的值
+func (f *FillForm) SetInputNodeValue(ctx context.Context, nodeID cdp.NodeID, value string) {
+	var nodeIds = []cdp.NodeID{nodeID}
+	// 先使用模拟输入
+	_ = chromedp.SendKeys(nodeIds, value, chromedp.ByNodeID).Do(ctx)
+	// 再直接赋值属性,date、number等类型模拟输入不一定生效,需要同时设置JS属性
+	_ = chromedp.SetAttributeValue(nodeIds, "value", value, chromedp.ByNodeID).Do(ctx)
+	_ = chromedp.SetJavascriptAttribute(nodeIds, "value", value, chromedp.ByNodeID).Do(ctx)
+}
+
 // GetMatchInputText 获取输入的表单值
 func (f *FillForm) GetMatchInputText(name string) string {
 	// 如果自定义了关键词，模糊匹配
@@ -254,10 +275,31 @@ func (f *FillForm) GetMatchInputText(name string) string {
 	return f.Tab.config.CustomFormValues["default"]
 }
 
-// GetNodeIDs 立即根据条件获取Nodes的ID，不等待
+// GetNodeIDs 立即根据条件获取Nodes的ID，不等待,查询会穿透shadow root并进入同进程的iframe
 func (tab *Tab) GetNodeIDs(sel string) ([]cdp.NodeID, error) {
 	ctx := tab.GetCDPExecutor()
-	return dom.QuerySelectorAll(tab.DocBodyNodeId, sel).Do(ctx)
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	rootIDs, err := tab.queryRootNodeIDs(tCtx)
+	cancel()
+	if err != nil {
+		return dom.QuerySelectorAll(tab.DocBodyNodeId, sel).Do(ctx)
+	}
+	var nodeIDs []cdp.NodeID
+	var lastErr error
+	for _, rootID := range rootIDs {
+		tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+		ids, err := dom.QuerySelectorAll(rootID, sel).Do(tCtx)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		nodeIDs = append(nodeIDs, ids...)
+	}
+	if len(nodeIDs) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return nodeIDs, nil
 }
 
 // EvaluateWithNode 用节点来执行表达式
@@ -296,7 +338,7 @@ func (tab *Tab) Evaluate(expression string) {
 func (tab *Tab) SetObserverJS() {
 	defer tab.DomWaitGroup.Done()
 	// 设置Dom节点变化的观察函数
-	go tab.Evaluate(enums2.ObserverJS)
+	go tab.EvaluateInAllFrames(enums2.ObserverJS)
 }
 
 // SetFormToFrame 设置表单为指定的模板
@@ -326,7 +368,7 @@ func (tab *Tab) ClickSubmitComponent() {
 	ctx := tab.GetCDPExecutor()
 
 	// 获取所有的form节点 直接执行submit
-	formNodes, formErr := tab.GetNodeIDs(`form`)
+	formNodes, formErr := tab.GetNodeIDs(`form:not([sec_auto_skip])`)
 	if formErr != nil || len(formNodes) == 0 {
 		if formErr != nil {
 			return
@@ -338,7 +380,7 @@ func (tab *Tab) ClickSubmitComponent() {
 	_ = chromedp.Submit(formNodes, chromedp.ByNodeID).Do(tCtx1) // 提交全部的表单
 
 	// 获取所有的input标签
-	inputNodes, inputErr := tab.GetNodeIDs(`form input[type=submit]`)
+	inputNodes, inputErr := tab.GetNodeIDs(`form input[type=submit]:not([sec_auto_skip])`)
 	if inputErr != nil || len(inputNodes) == 0 {
 		if inputErr != nil {
 			return
@@ -354,26 +396,22 @@ func (tab *Tab) ClickSubmitComponent() {
 func (tab *Tab) ClickHyperlink() {
 	defer tab.FormSubmitWaitGroup.Done()
 	ctx := tab.GetCDPExecutor()
-	var href []map[string]string
-	tCtx, cancel := context.WithTimeout(ctx, time.Second*1)
-	_ = chromedp.AttributesAll(`a[href]`, &href, chromedp.ByQueryAll).Do(tCtx)
-	cancel()
 	// 我们获取到全部的a href 标签
-	for _, v := range href {
+	for _, href := range tab.GetAttributeValues("href", false) {
 		// 如果我们获取的a标签不包含https或者http的话,证明是当前网站的链接
-		if !strings.Contains(v["href"], "https://") && !strings.Contains(v["href"], "http://") && !tab.HrefClick.Contains(v["href"]) {
+		if !strings.Contains(href, "https://") && !strings.Contains(href, "http://") && !tab.HrefClick.Contains(href) {
 			// 点击这个属性的按钮
-			tab.HrefClick.Add(v["href"])
+			tab.HrefClick.Add(href)
 		} else {
 			// 包含https或者http，并且根域也是当前的根域
-			if strings.Contains(v["href"], tab.config.RootDomain) && !tab.HrefClick.Contains(v["href"]) {
-				tab.HrefClick.Add(v["href"])
+			if strings.Contains(href, tab.config.RootDomain) && !tab.HrefClick.Contains(href) {
+				tab.HrefClick.Add(href)
 			}
 		}
 	}
 	// 遍历我们点击的按钮指定链接的按钮
 	for _, h := range tab.HrefClick.ToSlice() {
-		hrefNodes, err := tab.GetNodeIDs(fmt.Sprintf(`a[href="%s"]`, h))
+		hrefNodes, err := tab.GetNodeIDs(fmt.Sprintf(`a[href="%s"]:not([sec_auto_skip])`, h))
 		if err != nil {
 			continue
 		}
@@ -389,7 +427,7 @@ func (tab *Tab) ClickButtonComponent() {
 	// 首先获取当前tab的上下文
 	ctx := tab.GetCDPExecutor()
 	// 查找全部的的表单按钮
-	btnNodeIDs, bErr := tab.GetNodeIDs(`form button`)
+	btnNodeIDs, bErr := tab.GetNodeIDs(`form button:not([sec_auto_skip])`)
 	// 如果存在错误或者按钮数量为0的时候
 	if bErr != nil || len(btnNodeIDs) == 0 {
 		return
@@ -402,22 +440,15 @@ func (tab *Tab) ClickButtonComponent() {
 	_ = chromedp.Click(btnNodeIDs, chromedp.ByNodeID).Do(tCtx)
 
 	// 使用JS的click方法进行点击
-	var btnNodes []*cdp.Node
-	tCtx2, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
-	err := chromedp.Nodes(btnNodeIDs, &btnNodes, chromedp.ByNodeID).Do(tCtx2)
-	if err != nil {
-		return
-	}
-	for _, node := range btnNodes {
-		_ = tab.EvaluateWithNode(enums2.FormNodeClickJS, node)
+	for _, nodeID := range btnNodeIDs {
+		_ = tab.CallFunctionOnNode(nodeID, enums2.NodeClickFunction)
 	}
 }
 
 // TriggerJavascriptProtocol 触发javascript的伪协议
 func (tab *Tab) TriggerJavascriptProtocol() {
 	defer tab.LoadedWaitGroup.Done()
-	tab.Evaluate(fmt.Sprintf(enums2.TriggerJavascriptProtocol,
+	tab.EvaluateInAllFrames(fmt.Sprintf(enums2.TriggerJavascriptProtocol,
 		tab.config.EventTriggerInterval.Seconds()*1000,
 		tab.config.EventTriggerInterval.Seconds()*1000),
 	)
@@ -426,18 +457,53 @@ func (tab *Tab) TriggerJavascriptProtocol() {
 // TriggerInlineEvents 触发所有的内敛事件
 func (tab *Tab) TriggerInlineEvents() {
 	defer tab.LoadedWaitGroup.Done()
-	tab.Evaluate(fmt.Sprintf(enums2.TriggerInlineEventJS, tab.config.EventTriggerInterval.Seconds()*1000))
+	tab.EvaluateInAllFrames(fmt.Sprintf(enums2.TriggerInlineEventJS, tab.config.EventTriggerInterval.Seconds()*1000))
 }
 
 // TriggerDom2Events 触发dom 2级事件
 func (tab *Tab) TriggerDom2Events() {
 	defer tab.LoadedWaitGroup.Done()
-	tab.Evaluate(fmt.Sprintf(enums2.TriggerDom2EventJS, tab.config.EventTriggerInterval.Seconds()*1000))
+	tab.EvaluateInAllFrames(fmt.Sprintf(enums2.TriggerDom2EventJS, tab.config.EventTriggerInterval.Seconds()*1000))
+}
+
+// TriggerInfiniteScroll 逐步滚动页面,直到页面高度不再增长且没有可点击的"加载更多"按钮,或者滚动次数用完
+func (tab *Tab) TriggerInfiniteScroll() {
+	ctx := tab.GetCDPExecutor()
+	keywords, _ := json.Marshal(enums2.DefaultLoadMoreKeywords)
+	expression := fmt.Sprintf(enums2.InfiniteScrollStepJS, keywords)
+	lastHeight, stable := -1, 0
+	for i := 0; i < tab.config.ScrollMaxCount; i++ {
+		tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+		var resultJSON string
+		err := chromedp.Evaluate(expression, &resultJSON).Do(tCtx)
+		cancel()
+		if err != nil {
+			return
+		}
+		var result struct {
+			Height  int `json:"height"`
+			Clicked int `json:"clicked"`
+		}
+		if err = json.Unmarshal([]byte(resultJSON), &result); err != nil {
+			return
+		}
+		// 等待滚动和点击触发的XHR以及新内容渲染
+		time.Sleep(enums2.ScrollInterval)
+		if result.Height == lastHeight && result.Clicked == 0 {
+			stable++
+			if stable >= enums2.ScrollStableCount {
+				return
+			}
+		} else {
+			stable = 0
+		}
+		lastHeight = result.Height
+	}
 }
 
 // RemoveDOMListener 移除dom节点变化监听
 func (tab *Tab) RemoveDOMListener() {
 	defer tab.RemoveList.Done()
 	// 移除DOM节点变化监听
-	tab.Evaluate(enums2.RemoveDOMListenerJS)
+	tab.EvaluateInAllFrames(enums2.RemoveDOMListenerJS)
 }
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/chromedp"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"time"
+)
+
+// 这里处理单页应用的状态探索,每次动作后计算DOM状态hash,按照广度优先探索未见过的状态
+
+// ExploreStates 在动作预算内广度优先探索页面状态
+func (tab *Tab) ExploreStates() {
+	rootHash, ok := tab.DOMStateHash()
+	if !ok {
+		return
+	}
+	graph := NewStateGraph(tab.NavigateRequest.URL.String(), rootHash)
+	tab.StateGraph = graph
+	defer tab.SetActionPath(nil)
+
+	budget := tab.config.ExploreMaxActions
+	current := rootHash
+	for budget > 0 {
+		state, ok := graph.Next()
+		if !ok {
+			break
+		}
+		// 当前不在该状态时,需要重新导航并重放动作路径
+		if current != state.Hash {
+			if current, ok = tab.ReplayActionPath(state.Path); !ok || current != state.Hash {
+				continue
+			}
+		}
+		actions := tab.ListActions()
+		for _, action := range actions {
+			if budget <= 0 {
+				break
+			}
+			budget--
+			path := append(append([]httplib.Action{}, state.Path...), action)
+			tab.SetActionPath(path)
+			if !tab.PerformAction(action) {
+				continue
+			}
+			time.Sleep(enums2.ExploreSettleDelay)
+			if current, ok = tab.DOMStateHash(); !ok {
+				return
+			}
+			graph.AddTransition(state.Hash, action, current)
+			// 状态发生变化,回到当前探索的状态继续尝试其他动作
+			if current != state.Hash {
+				tab.SetActionPath(nil)
+				if current, ok = tab.ReplayActionPath(state.Path); !ok || current != state.Hash {
+					break
+				}
+			}
+		}
+		tab.SetActionPath(nil)
+	}
+}
+
+// SetActionPath 设置当前正在执行的动作路径,期间发现的请求都会记录该路径
+func (tab *Tab) SetActionPath(path []httplib.Action) {
+	tab.Lock.Lock()
+	tab.ActionPath = path
+	tab.Lock.Unlock()
+}
+
+// ReplayActionPath 重新导航到当前页面并依次执行动作路径,返回到达的状态hash
+func (tab *Tab) ReplayActionPath(path []httplib.Action) (string, bool) {
+	// 非GET的导航请求无法重新导航到相同的页面
+	if tab.NavigateRequest.Method != enums2.GET {
+		return "", false
+	}
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, tab.config.DomContentLoadedTimeout)
+	err := chromedp.Navigate(tab.NavigateRequest.URL.String()).Do(tCtx)
+	cancel()
+	if err != nil {
+		return "", false
+	}
+	time.Sleep(enums2.ExploreSettleDelay)
+	for _, action := range path {
+		if !tab.PerformAction(action) {
+			return "", false
+		}
+		time.Sleep(enums2.ExploreSettleDelay)
+	}
+	return tab.DOMStateHash()
+}
+
+// DOMStateHash 计算当前页面可见DOM结构和文本的hash
+func (tab *Tab) DOMStateHash() (string, bool) {
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	var hash string
+	if err := chromedp.Evaluate(enums2.DOMStateHashJS, &hash).Do(tCtx); err != nil || hash == "" {
+		return "", false
+	}
+	return hash, true
+}
+
+// ListActions 获取当前状态下可以执行的动作
+func (tab *Tab) ListActions() []httplib.Action {
+	tab.GuardDangerousElements()
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	var actionsJSON string
+	if err := chromedp.Evaluate(fmt.Sprintf(enums2.ListActionsJS, enums2.ExploreMaxStateActions), &actionsJSON).Do(tCtx); err != nil {
+		return nil
+	}
+	var actions []httplib.Action
+	if err := json.Unmarshal([]byte(actionsJSON), &actions); err != nil {
+		return nil
+	}
+	return actions
+}
+
+// PerformAction 在页面上执行一次动作,元素不存在时返回false
+func (tab *Tab) PerformAction(action httplib.Action) bool {
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	selector, _ := json.Marshal(action.Selector)
+	var found bool
+	if err := chromedp.Evaluate(fmt.Sprintf(enums2.PerformActionJS, selector), &found).Do(tCtx); err != nil {
+		return false
+	}
+	return found
+}
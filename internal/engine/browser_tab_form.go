@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"encoding/json"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+)
+
+// 这里处理表单的结构化提取,每一个表单都会合成一个请求,即使表单提交失败也能记录到提交地址
+
+// CollectForms 提取页面以及每个frame中的全部表单,记录结构化的表单并合成对应的请求
+func (tab *Tab) CollectForms() {
+	var forms []*httplib.Form
+	for _, formsJSON := range tab.EvaluateStringInAllFrames(enums2.ExtractFormsJS) {
+		var frameForms []*httplib.Form
+		if err := json.Unmarshal([]byte(formsJSON), &frameForms); err != nil {
+			continue
+		}
+		forms = append(forms, frameForms...)
+	}
+	f := FillForm{Tab: tab}
+	for _, form := range forms {
+		f.FillFormFieldValues(form)
+		tab.Lock.Lock()
+		tab.FormList = append(tab.FormList, form)
+		tab.Lock.Unlock()
+		tab.AddResultFormForm(form)
+	}
+}
+
+// FillFormFieldValues 为没有值的表单字段填充值
+func (f *FillForm) FillFormFieldValues(form *httplib.Form) {
+	for i := range form.Fields {
+		field := &form.Fields[i]
+		if field.Type != "file" && (field.Value != "" || field.Default != "") {
+			continue
+		}
+		switch field.Type {
+		case "file":
+			if f.Tab.config.UploadDir != nil {
+				field.File, _ = f.Tab.config.UploadDir.SampleFile(field.Accept)
+			}
+		case "checkbox", "radio", "hidden":
+			continue
+		case "select":
+			if len(field.Options) > 0 {
+				field.Value = field.Options[0]
+			}
+		case "email", "password", "tel":
+			field.Value = f.GetConstraintInputText(field.Constraints, field.Type)
+		default:
+			field.Value = f.GetConstraintInputText(field.Constraints, field.Name)
+		}
+	}
+}
+
+// AddResultFormForm 根据表单合成请求并添加到结果列表
+func (tab *Tab) AddResultFormForm(form *httplib.Form) {
+	req, err := form.ToRequest()
+	if err != nil {
+		return
+	}
+	// 添加Cookie
+	if cookie, ok := tab.NavigateRequest.Headers["Cookie"]; ok {
+		req.Headers["Cookie"] = cookie
+	}
+	req.Headers["Referer"] = form.URL
+	req.Source = enums2.FromForm
+	tab.AddTabRequestToResultList(req)
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"sort"
+	"time"
+)
+
+// 这里处理iframe和shadow DOM,节点查询通过DOM域穿透shadow root和同进程的iframe,js在每个frame的执行上下文中分别执行
+
+// executionContextAuxData 执行上下文的附加信息
+type executionContextAuxData struct {
+	IsDefault bool   `json:"isDefault"`
+	FrameID   string `json:"frameId"`
+}
+
+// HandleExecutionContextCreated 记录每个frame的默认执行上下文,扩展和隔离环境的上下文不记录
+func (tab *Tab) HandleExecutionContextCreated(v *runtime.EventExecutionContextCreated) {
+	var auxData executionContextAuxData
+	if err := json.Unmarshal(v.Context.AuxData, &auxData); err != nil || !auxData.IsDefault {
+		return
+	}
+	tab.FrameContextLock.Lock()
+	tab.FrameContexts[v.Context.ID] = auxData.FrameID
+	tab.FrameContextLock.Unlock()
+}
+
+// HandleExecutionContextDestroyed 移除已经销毁的执行上下文
+func (tab *Tab) HandleExecutionContextDestroyed(id runtime.ExecutionContextID) {
+	tab.FrameContextLock.Lock()
+	delete(tab.FrameContexts, id)
+	tab.FrameContextLock.Unlock()
+}
+
+// ClearExecutionContexts 页面导航后清空全部执行上下文
+func (tab *Tab) ClearExecutionContexts() {
+	tab.FrameContextLock.Lock()
+	tab.FrameContexts = make(map[runtime.ExecutionContextID]string)
+	tab.FrameContextLock.Unlock()
+}
+
+// FrameContextIDs 返回全部frame的执行上下文,按照创建顺序排列,顶层页面在最前
+func (tab *Tab) FrameContextIDs() []runtime.ExecutionContextID {
+	tab.FrameContextLock.Lock()
+	defer tab.FrameContextLock.Unlock()
+	ids := make([]runtime.ExecutionContextID, 0, len(tab.FrameContexts))
+	for id := range tab.FrameContexts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// inFrameContext 在指定的执行上下文中执行表达式
+func inFrameContext(id runtime.ExecutionContextID) chromedp.EvaluateOption {
+	return func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithContextID(id)
+	}
+}
+
+// EvaluateInAllFrames 在每个frame中执行js表达式,没有记录到执行上下文时在顶层页面执行
+func (tab *Tab) EvaluateInAllFrames(expression string) {
+	contextIDs := tab.FrameContextIDs()
+	if len(contextIDs) == 0 {
+		tab.Evaluate(expression)
+		return
+	}
+	ctx := tab.GetCDPExecutor()
+	for _, id := range contextIDs {
+		tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+		_, _, _ = runtime.Evaluate(expression).WithContextID(id).Do(tCtx)
+		cancel()
+	}
+}
+
+// EvaluateStringInAllFrames 在每个frame中执行返回字符串的js表达式,返回每个frame的结果
+func (tab *Tab) EvaluateStringInAllFrames(expression string) []string {
+	ctx := tab.GetCDPExecutor()
+	var results []string
+	evaluate := func(opts ...chromedp.EvaluateOption) {
+		tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+		defer cancel()
+		var result string
+		if err := chromedp.Evaluate(expression, &result, opts...).Do(tCtx); err == nil {
+			results = append(results, result)
+		}
+	}
+	contextIDs := tab.FrameContextIDs()
+	if len(contextIDs) == 0 {
+		evaluate()
+		return results
+	}
+	for _, id := range contextIDs {
+		evaluate(inFrameContext(id))
+	}
+	return results
+}
+
+// GetAttributeValues 获取全部frame和shadow root中元素的属性值,resolve为true时解析为绝对地址
+func (tab *Tab) GetAttributeValues(attr string, resolve bool) []string {
+	attrJSON, _ := json.Marshal(attr)
+	var values []string
+	for _, result := range tab.EvaluateStringInAllFrames(fmt.Sprintf(enums2.AttributeValuesJS, attrJSON, resolve)) {
+		var frameValues []string
+		if err := json.Unmarshal([]byte(result), &frameValues); err != nil {
+			continue
+		}
+		values = append(values, frameValues...)
+	}
+	return values
+}
+
+// queryRootNodeIDs 获取需要查询的根节点,包括body以及穿透得到的shadow root和同进程iframe的文档
+// 这里使用带pierce的describeNode而不是getDocument,getDocument会丢弃已经下发的节点ID,其他协程中持有的节点会失效
+func (tab *Tab) queryRootNodeIDs(ctx context.Context) ([]cdp.NodeID, error) {
+	body, err := dom.DescribeNode().WithNodeID(tab.DocBodyNodeId).WithDepth(-1).WithPierce(true).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var backendIDs []cdp.BackendNodeID
+	var walk func(node *cdp.Node)
+	walk = func(node *cdp.Node) {
+		for _, shadowRoot := range node.ShadowRoots {
+			// 浏览器内置控件的shadow root中没有页面内容
+			if shadowRoot.ShadowRootType == cdp.ShadowRootTypeUserAgent {
+				continue
+			}
+			backendIDs = append(backendIDs, shadowRoot.BackendNodeID)
+			walk(shadowRoot)
+		}
+		if node.ContentDocument != nil {
+			backendIDs = append(backendIDs, node.ContentDocument.BackendNodeID)
+			walk(node.ContentDocument)
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(body)
+	rootIDs := []cdp.NodeID{tab.DocBodyNodeId}
+	if len(backendIDs) == 0 {
+		return rootIDs, nil
+	}
+	nodeIDs, err := dom.PushNodesByBackendIDsToFrontend(backendIDs).Do(ctx)
+	if err != nil {
+		return rootIDs, nil
+	}
+	for _, nodeID := range nodeIDs {
+		if nodeID != 0 {
+			rootIDs = append(rootIDs, nodeID)
+		}
+	}
+	return rootIDs, nil
+}
+
+// GetNodes 获取全部frame和shadow root中匹配的节点,节点中包含属性信息
+func (tab *Tab) GetNodes(sel string) ([]*cdp.Node, error) {
+	nodeIDs, err := tab.GetNodeIDs(sel)
+	if err != nil {
+		return nil, err
+	}
+	ctx := tab.GetCDPExecutor()
+	var nodes []*cdp.Node
+	for _, nodeID := range nodeIDs {
+		tCtx, cancel := context.WithTimeout(ctx, time.Second*1)
+		node, err := dom.DescribeNode().WithNodeID(nodeID).Do(tCtx)
+		cancel()
+		if err != nil {
+			continue
+		}
+		node.NodeID = nodeID
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// CallFunctionOnNode 以节点作为this执行js函数
+func (tab *Tab) CallFunctionOnNode(nodeID cdp.NodeID, function string) error {
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	object, err := dom.ResolveNode().WithNodeID(nodeID).Do(tCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = runtime.ReleaseObject(object.ObjectID).Do(tCtx)
+	}()
+	_, exception, err := runtime.CallFunctionOn(function).WithObjectID(object.ObjectID).Do(tCtx)
+	if err != nil {
+		return err
+	}
+	if exception != nil {
+		return exception
+	}
+	return nil
+}
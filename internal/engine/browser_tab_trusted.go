@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"context"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"strings"
+	"time"
+)
+
+// 这里处理可信事件的触发,通过CDP的Input域在元素坐标上派发鼠标和键盘事件,页面中收到的事件isTrusted为true
+
+// 可信事件的类型
+const (
+	trustedHover    = "hover"
+	trustedClick    = "click"
+	trustedDblClick = "dblclick"
+	trustedFocus    = "focus"
+	trustedKey      = "key"
+)
+
+// trustedEventKinds 将页面中监听的事件名称归类为需要派发的可信输入
+func trustedEventKinds(events []string) map[string]bool {
+	kinds := map[string]bool{}
+	for _, event := range events {
+		switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(event)), "on") {
+		case "mouseover", "mouseenter", "mousemove", "mouseout", "mouseleave", "pointerover", "pointerenter", "pointermove":
+			kinds[trustedHover] = true
+		case "click", "mousedown", "mouseup", "pointerdown", "pointerup", "submit":
+			kinds[trustedClick] = true
+		case "dblclick", "dbclick":
+			kinds[trustedDblClick] = true
+		case "focus", "blur", "focusin", "focusout", "change", "select":
+			kinds[trustedFocus] = true
+		case "keydown", "keypress", "keyup", "input":
+			kinds[trustedKey] = true
+		}
+	}
+	return kinds
+}
+
+// TriggerTrustedInlineEvents 对带有内联事件属性的元素派发可信事件
+func (tab *Tab) TriggerTrustedInlineEvents() {
+	defer tab.LoadedWaitGroup.Done()
+	var selectors []string
+	for _, name := range enums2.InlineEventNames {
+		selectors = append(selectors, "["+name+"]")
+	}
+	nodes, err := tab.GetNodes(strings.Join(selectors, ","))
+	if err != nil {
+		return
+	}
+	for i, node := range nodes {
+		if i >= enums2.TrustedEventMaxNodes {
+			break
+		}
+		var events []string
+		for j := 0; j+1 < len(node.Attributes); j += 2 {
+			if strings.HasPrefix(node.Attributes[j], "on") {
+				events = append(events, node.Attributes[j])
+			}
+		}
+		tab.DispatchTrustedEvents(node, events)
+		time.Sleep(tab.config.EventTriggerInterval)
+	}
+}
+
+// TriggerTrustedDom2Events 对通过addEventListener添加了监听的元素派发可信事件
+func (tab *Tab) TriggerTrustedDom2Events() {
+	defer tab.LoadedWaitGroup.Done()
+	nodes, err := tab.GetNodes(`[sec_auto_dom2_event_flag]`)
+	if err != nil {
+		return
+	}
+	for i, node := range nodes {
+		if i >= enums2.TrustedEventMaxNodes {
+			break
+		}
+		// 和dom2级事件的脚本一致,跳过关闭按钮
+		if strings.Contains(node.AttributeValue("class"), "close") || strings.Contains(node.AttributeValue("id"), "close") {
+			continue
+		}
+		tab.DispatchTrustedEvents(node, strings.Split(node.AttributeValue("sec_auto_dom2_event_flag"), "|"))
+		time.Sleep(tab.config.EventTriggerInterval)
+	}
+}
+
+// DispatchTrustedEvents 将元素滚动到可见区域后,在元素中心派发悬停、点击、聚焦和按键事件
+func (tab *Tab) DispatchTrustedEvents(node *cdp.Node, events []string) {
+	kinds := trustedEventKinds(events)
+	if _, skip := node.Attribute("sec_auto_skip"); skip || len(kinds) == 0 {
+		return
+	}
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	x, y, ok := tab.NodeCenter(tCtx, node.NodeID)
+	if !ok {
+		return
+	}
+	// 所有鼠标事件之前都先移动到元素上,悬停菜单也依赖这一步
+	_ = input.DispatchMouseEvent(input.MouseMoved, x, y).Do(tCtx)
+	if kinds[trustedClick] || kinds[trustedDblClick] {
+		_ = input.DispatchMouseEvent(input.MousePressed, x, y).WithButton(input.Left).WithClickCount(1).Do(tCtx)
+		_ = input.DispatchMouseEvent(input.MouseReleased, x, y).WithButton(input.Left).WithClickCount(1).Do(tCtx)
+	}
+	if kinds[trustedDblClick] {
+		_ = input.DispatchMouseEvent(input.MousePressed, x, y).WithButton(input.Left).WithClickCount(2).Do(tCtx)
+		_ = input.DispatchMouseEvent(input.MouseReleased, x, y).WithButton(input.Left).WithClickCount(2).Do(tCtx)
+	}
+	if kinds[trustedFocus] || kinds[trustedKey] {
+		_ = dom.Focus().WithNodeID(node.NodeID).Do(tCtx)
+	}
+	if kinds[trustedKey] {
+		_ = input.DispatchKeyEvent(input.KeyDown).WithKey("a").WithCode("KeyA").WithText("a").WithWindowsVirtualKeyCode(65).Do(tCtx)
+		_ = input.DispatchKeyEvent(input.KeyUp).WithKey("a").WithCode("KeyA").WithWindowsVirtualKeyCode(65).Do(tCtx)
+	}
+	// 悬停事件需要移出元素才能触发mouseout
+	if kinds[trustedHover] {
+		_ = input.DispatchMouseEvent(input.MouseMoved, 0, 0).Do(tCtx)
+	}
+}
+
+// NodeCenter 将节点滚动到可见区域并返回节点内容区域的中心坐标,不可见的节点返回false
+func (tab *Tab) NodeCenter(ctx context.Context, nodeID cdp.NodeID) (float64, float64, bool) {
+	_ = dom.ScrollIntoViewIfNeeded().WithNodeID(nodeID).Do(ctx)
+	box, err := dom.GetBoxModel().WithNodeID(nodeID).Do(ctx)
+	if err != nil || box == nil || len(box.Content) < 8 || box.Width == 0 || box.Height == 0 {
+		return 0, 0, false
+	}
+	var x, y float64
+	for i := 0; i < 8; i += 2 {
+		x += box.Content[i]
+		y += box.Content[i+1]
+	}
+	return x / 4, y / 4, true
+}
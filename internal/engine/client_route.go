@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/chromedp"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/option"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 这里处理React、Vue、Angular等单页应用的前端路由,从脚本中解析路由表并通过应用自身的路由访问
+
+var (
+	// routePathRegex 路由表中的 path: "/user/:id",Vue、Angular、React Router的对象写法都会编译成这种形式
+	routePathRegex = regexp.MustCompile("\\bpath\\s*:\\s*[\"'`]([^\"'`\\s]{0,100})[\"'`]")
+	// routeKeyRegex 路由对象中才会出现的键,path所在的对象中没有这些键时不是路由表,例如 {path:"/api",method:"get"}
+	routeKeyRegex = regexp.MustCompile(`\b(component|components|children|element|Component|loadChildren|loadComponent|lazy|redirect|redirectTo)\s*:`)
+	// routeCallRegex React Router 编译后的 createElement(Route,{path:"/user"}) 写法,对象直接作为Route的属性
+	routeCallRegex = regexp.MustCompile(`\bRoute\s*,\s*$`)
+	// childrenKeyRegex 子路由数组 children:[...],其中的相对路径相对于父路由
+	childrenKeyRegex = regexp.MustCompile(`\bchildren\s*:\s*$`)
+	// jsxRoutePathRegex 未编译的 <Route path="/user/:id" />
+	jsxRoutePathRegex = regexp.MustCompile(`<Route\b[^>]*?\bpath\s*=\s*\{?["']([^"']{0,100})["']`)
+	// buildManifestPageRegex Next.js __BUILD_MANIFEST 中的页面路由
+	buildManifestPageRegex = regexp.MustCompile(`"(/[^"\s]{0,100})"\s*:\s*\[`)
+	// pageChunkRegex Next.js 按页面拆分的懒加载chunk,例如 static/chunks/pages/about-0123456789abcdef.js
+	pageChunkRegex = regexp.MustCompile(`static/chunks/pages(/[\w\-/\[\].]{0,100}?)-[0-9a-f]{16,}\.js`)
+	// routeParamRegex 路由参数 :id、:id(\d+)、:path*
+	routeParamRegex = regexp.MustCompile(`:[A-Za-z_$][\w$]*(\([^)]*\))?[?+*]?`)
+	// dynamicSegmentRegex Next.js 的动态路由 [id]、[...slug]
+	dynamicSegmentRegex = regexp.MustCompile(`\[{1,2}(\.\.\.)?[^\]]+\]{1,2}`)
+	routeCharsRegex     = regexp.MustCompile(`^[\w\-./~%@!$&'()+,;=]*$`)
+)
+
+// ExtractClientRoutes 从脚本内容中解析前端路由,返回去重后的路由路径,路由参数替换为1
+func ExtractClientRoutes(js string) []string {
+	var routes []string
+	seen := map[string]bool{}
+	add := func(raw string) {
+		route, ok := normalizeClientRoute(raw)
+		if !ok || seen[route] {
+			return
+		}
+		seen[route] = true
+		routes = append(routes, route)
+	}
+	// Angular懒加载模块中的路由相对于加载它的父路由,脚本中无法知道挂载位置
+	lazyModule := strings.Contains(js, "forChild(")
+	for _, match := range routePathRegex.FindAllStringSubmatchIndex(js, -1) {
+		objStart := enclosingIndex(js, match[0], '{', '}', enums2.ClientRouteObjectRange)
+		if objStart < 0 || !isRouteObject(js, objStart, match[1]) {
+			continue
+		}
+		if route, ok := resolveClientRoute(js, objStart, js[match[2]:match[3]], lazyModule); ok {
+			add(route)
+		}
+	}
+	for _, match := range jsxRoutePathRegex.FindAllStringSubmatch(js, -1) {
+		add(match[1])
+	}
+	if strings.Contains(js, "__BUILD_MANIFEST") {
+		for _, match := range buildManifestPageRegex.FindAllStringSubmatch(js, -1) {
+			add(match[1])
+		}
+	}
+	for _, match := range pageChunkRegex.FindAllStringSubmatch(js, -1) {
+		add(strings.TrimSuffix(match[1], "/index"))
+	}
+	return routes
+}
+
+// enclosingIndex 按照括号的嵌套层级向前查找包含pos的左括号位置,只在附近查找,没有找到返回-1
+func enclosingIndex(js string, pos int, open byte, close byte, limit int) int {
+	depth := 0
+	for i := pos - 1; i >= 0 && i >= pos-limit; i-- {
+		if js[i] == close {
+			depth++
+		} else if js[i] == open {
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// isRouteObject 判断从objStart开始的对象字面量是否为路由对象,对象中需要有路由才会出现的键,或者对象是Route组件的属性
+func isRouteObject(js string, objStart int, end int) bool {
+	// 按照括号的嵌套层级找到对象的结束位置,嵌套的子对象不影响判断
+	objEnd, depth := len(js), 0
+	for i := end; i < len(js); i++ {
+		if i >= end+enums2.ClientRouteObjectRange {
+			objEnd = i
+			break
+		}
+		if js[i] == '{' {
+			depth++
+		} else if js[i] == '}' {
+			if depth == 0 {
+				objEnd = i
+				break
+			}
+			depth--
+		}
+	}
+	if routeKeyRegex.MatchString(js[objStart:objEnd]) {
+		return true
+	}
+	from := objStart - enums2.ClientRouteObjectRange
+	if from < 0 {
+		from = 0
+	}
+	return routeCallRegex.MatchString(js[from:objStart])
+}
+
+// resolveClientRoute 解析路由的完整路径,children中的相对路径拼接在父路由之后,
+// 找不到父路由的子路由和懒加载模块中的相对路径无法确定完整路径,返回false
+func resolveClientRoute(js string, objStart int, raw string, lazyModule bool) (string, bool) {
+	if strings.HasPrefix(raw, "/") {
+		return raw, true
+	}
+	arrStart := enclosingIndex(js, objStart, '[', ']', enums2.ClientRouteParentRange)
+	from := arrStart - enums2.ClientRouteObjectRange
+	if from < 0 {
+		from = 0
+	}
+	if arrStart < 0 || !childrenKeyRegex.MatchString(js[from:arrStart]) {
+		// 顶层路由的相对路径相对于根路径,例如Angular的forRoot
+		return raw, !lazyModule
+	}
+	parentStart := enclosingIndex(js, arrStart, '{', '}', enums2.ClientRouteParentRange)
+	if parentStart < 0 {
+		return "", false
+	}
+	// 父路由的path在children之前,并且需要是父路由对象自身的键
+	for _, match := range routePathRegex.FindAllStringSubmatchIndex(js[parentStart:arrStart], -1) {
+		if enclosingIndex(js, parentStart+match[0], '{', '}', enums2.ClientRouteObjectRange) != parentStart {
+			continue
+		}
+		parent, ok := resolveClientRoute(js, parentStart, js[parentStart+match[2]:parentStart+match[3]], lazyModule)
+		if !ok {
+			return "", false
+		}
+		if raw == "" {
+			return parent, true
+		}
+		return strings.TrimSuffix(parent, "/") + "/" + raw, true
+	}
+	return "", false
+}
+
+// normalizeClientRoute 规范化路由路径,相对路径按照根路径处理,通配符路由和静态资源返回false
+func normalizeClientRoute(raw string) (string, bool) {
+	route := strings.TrimPrefix(strings.TrimSpace(raw), "#")
+	if strings.Contains(route, "://") || strings.HasPrefix(route, "//") {
+		return "", false
+	}
+	route = routeParamRegex.ReplaceAllString(route, "1")
+	route = dynamicSegmentRegex.ReplaceAllString(route, "1")
+	if strings.Contains(route, "*") || !routeCharsRegex.MatchString(route) {
+		return "", false
+	}
+	if !strings.HasPrefix(route, "/") {
+		route = "/" + route
+	}
+	for strings.Contains(route, "//") {
+		route = strings.Replace(route, "//", "/", -1)
+	}
+	// Next.js 内部页面
+	if strings.HasPrefix(route, "/_") {
+		return "", false
+	}
+	ext := strings.TrimPrefix(path.Ext(route), ".")
+	if ext == "js" || ext == "css" || option.StaticSuffixSet.Contains(ext) {
+		return "", false
+	}
+	return route, true
+}
+
+// AddClientRoutes 记录从脚本中解析出来的前端路由
+func (tab *Tab) AddClientRoutes(routes []string) {
+	for _, route := range routes {
+		tab.ClientRouteSet.Add(route)
+	}
+}
+
+// VisitClientRoutes 合并脚本中解析和运行时路由器中获取的路由,并通过应用自身的路由逐个访问,捕获路由级别的XHR
+func (tab *Tab) VisitClientRoutes() {
+	ctx := tab.GetCDPExecutor()
+	tab.Evaluate(enums2.ClientRouterJS)
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	var routerJSON string
+	_ = chromedp.Evaluate(enums2.ClientRoutesJS, &routerJSON).Do(tCtx)
+	cancel()
+	var router struct {
+		Mode   string   `json:"mode"`
+		Routes []string `json:"routes"`
+	}
+	_ = json.Unmarshal([]byte(routerJSON), &router)
+	for _, raw := range router.Routes {
+		if route, ok := normalizeClientRoute(raw); ok {
+			tab.ClientRouteSet.Add(route)
+		}
+	}
+
+	navURL := *tab.NavigateRequest.URL
+	navURL.Fragment = ""
+	// 集合的遍历顺序是随机的,排序后再截取,每次访问的路由保持一致
+	var routes []string
+	for _, item := range tab.ClientRouteSet.ToSlice() {
+		routes = append(routes, item.(string))
+	}
+	sort.Strings(routes)
+	if len(routes) > enums2.ClientRouteMaxCount {
+		routes = routes[:enums2.ClientRouteMaxCount]
+	}
+	for _, route := range routes {
+		// hash模式的路由在当前页面的fragment中
+		if router.Mode == "hash" {
+			tab.AddResultFormCustomUrl(enums2.GET, navURL.String()+"#"+route, enums2.FromClientRoute)
+		} else {
+			tab.AddResultFormCustomUrl(enums2.GET, route, enums2.FromClientRoute)
+		}
+		routeJSON, _ := json.Marshal(route)
+		tab.Evaluate(fmt.Sprintf(enums2.ClientRouteNavigateJS, routeJSON))
+		time.Sleep(enums2.ExploreSettleDelay)
+	}
+}
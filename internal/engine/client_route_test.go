@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractClientRoutes(t *testing.T) {
+	for _, item := range []struct {
+		name   string
+		js     string
+		routes []string
+	}{
+		{
+			name:   "vue",
+			js:     `const routes=[{path:"/",component:Home},{path:"/user/:id(\\d+)",component:User},{path:"/:pathMatch(.*)*",component:NotFound},{path:"*",redirect:"/"}]`,
+			routes: []string{"/", "/user/1", "/1"},
+		},
+		{
+			name:   "angular",
+			js:     `RouterModule.forRoot([{path:'',component:a},{path:'admin',loadChildren:()=>import('./admin')},{path:'**',component:b}])`,
+			routes: []string{"/", "/admin"},
+		},
+		{
+			name:   "nested",
+			js:     `[{path:'/user/:id',component:U,children:[{path:'',component:H},{path:'profile',component:P,children:[{path:'edit',component:E}]},{path:'/absolute',component:A}]},{path:'settings',children:[{path:'a',component:A}]}]`,
+			routes: []string{"/user/1", "/user/1/profile", "/user/1/profile/edit", "/absolute", "/settings", "/settings/a"},
+		},
+		{
+			name:   "lazy module",
+			js:     `const routes=[{path:'',component:List},{path:'detail/:id',component:Detail},{path:'/help',component:Help}];RouterModule.forChild(routes)`,
+			routes: []string{"/help"},
+		},
+		{
+			name:   "react",
+			js:     `<Route path="/orders/:orderId?" element={<Order/>}/>` + "\n" + `createElement(Route,{path:` + "`/settings`" + `})`,
+			routes: []string{"/settings", "/orders/1"},
+		},
+		{
+			name:   "next",
+			js:     `self.__BUILD_MANIFEST={"/":["static/chunks/pages/index-0123456789abcdef.js"],"/_error":[],"/post/[id]":["static/chunks/pages/post/[id]-fedcba9876543210.js"],"/about":["static/chunks/pages/about-00112233445566778.js"]}`,
+			routes: []string{"/", "/post/1", "/about"},
+		},
+		{
+			name:   "not router",
+			js:     `request({path:"/api/users",method:"get"});const link={path:"/account",title:"Account"};` + `{path:"/orders",meta:{title:"Orders"},component:Orders}`,
+			routes: []string{"/orders"},
+		},
+		{
+			name:   "ignored",
+			js:     `{path:"https://example.com/",component:A},{path:"/static/logo.png",component:A},{path:"/app.js",component:A},{path:"a b",component:A}`,
+			routes: nil,
+		},
+	} {
+		if routes := ExtractClientRoutes(item.js); !reflect.DeepEqual(routes, item.routes) {
+			t.Fatalf("%s: expected %v, got %v", item.name, item.routes, routes)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"strings"
+	"time"
+)
+
+// 这里处理危险操作的防护,点击和提交之前检查元素的语义,匹配危险关键字的元素标记为sec_auto_skip,之后的点击、事件触发和状态探索都会跳过
+
+// ElementSemantics 元素的语义信息
+type ElementSemantics struct {
+	Tag       string `json:"tag"`
+	Text      string `json:"text"`
+	AriaLabel string `json:"aria_label"`
+	ID        string `json:"id"`
+	Class     string `json:"class"`
+	Title     string `json:"title"`
+	Value     string `json:"value"`
+	Href      string `json:"href"`
+	Action    string `json:"action"` // 表单或提交按钮所在表单的提交地址
+}
+
+// SkippedElement 因为匹配危险关键字而跳过的元素
+type SkippedElement struct {
+	URL     string `json:"url"`     // 元素所在的页面
+	Tag     string `json:"tag"`     // 元素标签
+	Text    string `json:"text"`    // 元素文本
+	Field   string `json:"field"`   // 匹配上的字段
+	Keyword string `json:"keyword"` // 匹配上的关键字
+}
+
+// MatchDangerKeyword 按照文本、aria-label、title、value、id、class、表单提交地址、链接的顺序匹配危险关键字,不区分大小写
+func MatchDangerKeyword(el ElementSemantics, keywords []string) (string, string, bool) {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"text", el.Text},
+		{"aria-label", el.AriaLabel},
+		{"title", el.Title},
+		{"value", el.Value},
+		{"id", el.ID},
+		{"class", el.Class},
+		{"action", el.Action},
+		{"href", el.Href},
+	}
+	for _, field := range fields {
+		value := strings.ToLower(field.value)
+		if value == "" {
+			continue
+		}
+		for _, keyword := range keywords {
+			if keyword != "" && strings.Contains(value, strings.ToLower(keyword)) {
+				return field.name, keyword, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// GuardDangerousElements 检查每个frame中新出现的可点击元素和表单,标记并记录危险元素
+func (tab *Tab) GuardDangerousElements() {
+	if len(tab.config.DangerKeywords) == 0 {
+		return
+	}
+	contextIDs := tab.FrameContextIDs()
+	if len(contextIDs) == 0 {
+		tab.guardDangerousElements()
+		return
+	}
+	for _, id := range contextIDs {
+		tab.guardDangerousElements(inFrameContext(id))
+	}
+}
+
+// guardDangerousElements 在指定的执行上下文中检查元素,语义信息的获取和标记需要在同一个frame中执行
+func (tab *Tab) guardDangerousElements(opts ...chromedp.EvaluateOption) {
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	var semanticsJSON string
+	if err := chromedp.Evaluate(enums2.ElementSemanticsJS, &semanticsJSON, opts...).Do(tCtx); err != nil {
+		return
+	}
+	var elements []ElementSemantics
+	if err := json.Unmarshal([]byte(semanticsJSON), &elements); err != nil {
+		return
+	}
+	type markItem struct {
+		Index   int    `json:"index"`
+		Keyword string `json:"keyword"`
+	}
+	var items []markItem
+	for index, el := range elements {
+		field, keyword, ok := MatchDangerKeyword(el, tab.config.DangerKeywords)
+		if !ok {
+			continue
+		}
+		items = append(items, markItem{Index: index, Keyword: keyword})
+		tab.AddSkippedElement(&SkippedElement{
+			URL:     tab.NavigateRequest.URL.String(),
+			Tag:     el.Tag,
+			Text:    el.Text,
+			Field:   field,
+			Keyword: keyword,
+		})
+	}
+	if len(items) == 0 {
+		return
+	}
+	itemsJSON, _ := json.Marshal(items)
+	var res *runtime.RemoteObject
+	_ = chromedp.Evaluate(fmt.Sprintf(enums2.MarkSkipElementsJS, itemsJSON), &res, opts...).Do(tCtx)
+}
+
+// AddSkippedElement 记录跳过的危险元素
+func (tab *Tab) AddSkippedElement(el *SkippedElement) {
+	tab.Lock.Lock()
+	tab.SkippedElementList = append(tab.SkippedElementList, el)
+	tab.Lock.Unlock()
+}
+
+// IsSafeMethod 判断请求方法是否为只读的方法
+func IsSafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case enums2.GET, enums2.HEAD, enums2.OPTIONS:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"testing"
+)
+
+func TestMatchDangerKeyword(t *testing.T) {
+	for _, item := range []struct {
+		el    ElementSemantics
+		field string
+	}{
+		{ElementSemantics{Tag: "button", Text: "Delete account"}, "text"},
+		{ElementSemantics{Tag: "button", AriaLabel: "Remove item"}, "aria-label"},
+		{ElementSemantics{Tag: "a", ID: "btn-signout"}, "id"},
+		{ElementSemantics{Tag: "button", Text: "确定", Action: "/api/user/destroy"}, "action"},
+		{ElementSemantics{Tag: "span", Class: "icon 删除"}, "class"},
+	} {
+		field, _, ok := MatchDangerKeyword(item.el, enums2.DefaultDangerKeywords)
+		if !ok || field != item.field {
+			t.Fatalf("%+v: expected match on %s, got %s", item.el, item.field, field)
+		}
+	}
+	for _, el := range []ElementSemantics{
+		{Tag: "button", Text: "Save", Class: "btn dropdown-toggle"},
+		{Tag: "a", Text: "Profile", Href: "/user/1"},
+		{Tag: "form", Action: "/search"},
+	} {
+		if _, keyword, ok := MatchDangerKeyword(el, enums2.DefaultDangerKeywords); ok {
+			t.Fatalf("%+v: unexpected match on %s", el, keyword)
+		}
+	}
+}
+
+func TestIsSafeMethod(t *testing.T) {
+	for method, safe := range map[string]bool{"GET": true, "head": true, "OPTIONS": true, "POST": false, "DELETE": false, "PUT": false} {
+		if IsSafeMethod(method) != safe {
+			t.Fatalf("%s: expected %v", method, safe)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"os"
+)
+
+// 这里处理设备和视口的模拟
+
+// LoadDeviceProfiles 从json文件中加载自定义设备配置,支持数组或者以名称为键的对象
+func LoadDeviceProfiles(path string) (map[string]enums2.DeviceProfile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles := map[string]enums2.DeviceProfile{}
+	var profileList []enums2.DeviceProfile
+	if err = json.Unmarshal(content, &profileList); err == nil {
+		for _, profile := range profileList {
+			profiles[profile.Name] = profile
+		}
+		return profiles, nil
+	}
+	if err = json.Unmarshal(content, &profiles); err != nil {
+		return nil, err
+	}
+	for name, profile := range profiles {
+		if profile.Name == "" {
+			profile.Name = name
+			profiles[name] = profile
+		}
+	}
+	return profiles, nil
+}
+
+// GetDeviceProfiles 根据名称获取设备配置,自定义配置优先于内置配置
+func GetDeviceProfiles(names []string, custom map[string]enums2.DeviceProfile) ([]enums2.DeviceProfile, error) {
+	var profiles []enums2.DeviceProfile
+	if len(names) == 0 {
+		names = []string{enums2.DefaultDeviceProfile}
+	}
+	for _, name := range names {
+		if profile, ok := custom[name]; ok {
+			profiles = append(profiles, profile)
+		} else if profile, ok := enums2.DefaultDeviceProfiles[name]; ok {
+			profiles = append(profiles, profile)
+		} else {
+			return nil, fmt.Errorf("unknown device profile: %s", name)
+		}
+	}
+	return profiles, nil
+}
+
+// EmulateDevice 按照tab页的设备配置设置视口、触摸以及navigator属性,需要在导航之前执行
+func (tab *Tab) EmulateDevice() chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		device := tab.config.Device
+		if device.Width > 0 && device.Height > 0 {
+			err := emulation.SetDeviceMetricsOverride(device.Width, device.Height, device.DeviceScaleFactor, device.Mobile).Do(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		if device.Touch {
+			maxTouchPoints := device.MaxTouchPoints
+			if maxTouchPoints == 0 {
+				maxTouchPoints = 1
+			}
+			if err := emulation.SetTouchEmulationEnabled(true).WithMaxTouchPoints(maxTouchPoints).Do(ctx); err != nil {
+				return err
+			}
+		}
+		_, err := page.AddScriptToEvaluateOnNewDocument(fmt.Sprintf(enums2.DeviceInitJS,
+			device.Vendor, device.HardwareConcurrency, device.DeviceMemory)).Do(ctx)
+		return err
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDeviceProfiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, item := range []struct {
+		name    string
+		content string
+		devices map[string]int64 // 设备名称和视口宽度
+		wantErr bool
+	}{
+		{
+			name:    "array",
+			content: `[{"name":"tablet","width":800,"height":1280},{"name":"tv","width":3840,"height":2160}]`,
+			devices: map[string]int64{"tablet": 800, "tv": 3840},
+		},
+		{
+			name:    "object",
+			content: `{"tablet":{"width":800,"height":1280},"tv":{"name":"tv","width":3840}}`,
+			devices: map[string]int64{"tablet": 800, "tv": 3840},
+		},
+		{name: "malformed", content: `[{"name":"tablet",`, wantErr: true},
+		{name: "wrong type", content: `"tablet"`, wantErr: true},
+	} {
+		path := filepath.Join(dir, item.name+".json")
+		if err := os.WriteFile(path, []byte(item.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		profiles, err := LoadDeviceProfiles(path)
+		if item.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected error", item.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", item.name, err)
+		}
+		if len(profiles) != len(item.devices) {
+			t.Fatalf("%s: expected %d profiles, got %d", item.name, len(item.devices), len(profiles))
+		}
+		for name, width := range item.devices {
+			if profile, ok := profiles[name]; !ok || profile.Name != name || profile.Width != width {
+				t.Fatalf("%s: unexpected profile %s: %+v", item.name, name, profile)
+			}
+		}
+	}
+	if _, err := LoadDeviceProfiles(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestGetDeviceProfiles(t *testing.T) {
+	custom := map[string]enums2.DeviceProfile{
+		"tablet":  {Name: "tablet", Width: 800},
+		"desktop": {Name: "desktop", Width: 1280},
+	}
+	for _, item := range []struct {
+		names   []string
+		custom  map[string]enums2.DeviceProfile
+		widths  []int64
+		wantErr bool
+	}{
+		{names: nil, widths: []int64{enums2.DefaultDeviceProfiles[enums2.DefaultDeviceProfile].Width}},
+		{names: []string{"iphone", "ipad"}, widths: []int64{390, 820}},
+		{names: []string{"tablet", "iphone"}, custom: custom, widths: []int64{800, 390}},
+		{names: []string{"desktop"}, custom: custom, widths: []int64{1280}},
+		{names: []string{"iphone", "unknown"}, wantErr: true},
+		{names: []string{"tablet"}, wantErr: true},
+	} {
+		profiles, err := GetDeviceProfiles(item.names, item.custom)
+		if item.wantErr {
+			if err == nil {
+				t.Fatalf("%v: expected error", item.names)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: %v", item.names, err)
+		}
+		if len(profiles) != len(item.widths) {
+			t.Fatalf("%v: expected %d profiles, got %d", item.names, len(item.widths), len(profiles))
+		}
+		for i, width := range item.widths {
+			if profiles[i].Width != width {
+				t.Fatalf("%v: profile %d expected width %d, got %d", item.names, i, width, profiles[i].Width)
+			}
+		}
+	}
+}
@@ -35,25 +35,10 @@ const TabInitJS = `
 			originalQuery(parameters)
 	);
 
-	//Pass the Permissions Test. navigator.userAgent
-	Object.defineProperty(navigator, 'userAgent', {
-        get: () => "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/79.0.3945.0 Safari/537.36",
-    });
+	// navigator.userAgent、platform、language 由指纹配置生成的 NavigatorInitJS 设置
 
-	// 修改浏览器对象的属性
-	Object.defineProperty(navigator, 'platform', {
-		get: function () { return 'win32'; }
-	});
-	
-	Object.defineProperty(navigator, 'language', {
-		get: function () { return 'zh-CN'; }
-	});
-	
-	Object.defineProperty(navigator, 'languages', {
-		get: function () { return ["zh-CN", "zh"]; }
-	});
-	
-	// history api hook
+	// history api hook, 保留原始的pushState用于通过前端路由访问页面
+	window.__sec_auto_original_push_state = window.history.pushState;
 	window.history.pushState = function(a, b, c) { 
 		window.addLink(c, "HistoryAPI");
 	}
@@ -79,6 +64,26 @@ const TabInitJS = `
 		return new oldEventSource(url);
 	}
 	
+	// hook service worker 和 worker 的脚本地址,importScripts 只存在于worker中,在获取脚本后解析
+	if (window.ServiceWorkerContainer) {
+		var oldRegister = ServiceWorkerContainer.prototype.register;
+		ServiceWorkerContainer.prototype.register = function(url) {
+			window.addLink(String(url), "ServiceWorker");
+			return oldRegister.apply(this, arguments);
+		}
+	}
+	["Worker", "SharedWorker"].forEach(function(name) {
+		var oldWorker = window[name];
+		if (!oldWorker) {
+			return;
+		}
+		window[name] = function(url, options) {
+			window.addLink(String(url), "Worker");
+			return new oldWorker(url, options);
+		}
+		window[name].prototype = oldWorker.prototype;
+	});
+	
 	var oldFetch = window.fetch;
 	window.fetch = function(url) {
 		window.addLink(url, "Fetch");
@@ -220,6 +225,23 @@ const TabInitJS = `
 	}
 	Object.defineProperty(XMLHttpRequest.prototype,"abort",{"writable": false, "configurable": false});
 	
+	// 穿透open shadow root查询元素
+	window.sec_auto_query_all = function(selector) {
+		let result = [];
+		function walk(root) {
+			for (let el of root.querySelectorAll(selector)) {
+				result.push(el);
+			}
+			for (let el of root.querySelectorAll("*")) {
+				if (el.shadowRoot) {
+					walk(el.shadowRoot);
+				}
+			}
+		}
+		walk(document);
+		return result;
+	}
+
 	// 打乱数组的方法
 	window.randArr = function (arr) {
 		for (var i = 0; i < arr.length; i++) {
@@ -281,6 +303,42 @@ const TabInitJS = `
 })();
 `
 
+// NavigatorInitJS 设置指纹配置中的navigator属性
+const NavigatorInitJS = `
+(function addNavigatorInitScript (platform, languages) {
+	Object.defineProperty(navigator, 'platform', {
+		get: function () { return platform; }
+	});
+	Object.defineProperty(navigator, 'language', {
+		get: function () { return languages[0]; }
+	});
+	Object.defineProperty(navigator, 'languages', {
+		get: function () { return languages; }
+	});
+})(%s, %s);
+`
+
+// DeviceInitJS 设置设备配置中的navigator属性
+const DeviceInitJS = `
+(function addDeviceInitScript (vendor, hardwareConcurrency, deviceMemory) {
+	if (vendor !== "") {
+		Object.defineProperty(navigator, 'vendor', {
+			get: function () { return vendor; }
+		});
+	}
+	if (hardwareConcurrency > 0) {
+		Object.defineProperty(navigator, 'hardwareConcurrency', {
+			get: function () { return hardwareConcurrency; }
+		});
+	}
+	if (deviceMemory > 0) {
+		Object.defineProperty(navigator, 'deviceMemory', {
+			get: function () { return deviceMemory; }
+		});
+	}
+})(%q, %d, %d);
+`
+
 const DeliverResultJS = `
 (function deliverResult(name, seq, result) {
 	window[name]['callbacks'].get(seq)(result);
@@ -352,12 +410,15 @@ const TriggerInlineEventJS = `
 	let eventNames = ["onabort", "onblur", "onchange", "onclick", "ondblclick", "onerror", "onfocus", "onkeydown", "onkeypress", "onkeyup", "onload", "onmousedown", "onmousemove", "onmouseout", "onmouseover", "onmouseup", "onreset", "onresize", "onselect", "onsubmit", "onunload"];
 	for (let eventName of eventNames) {
 		let event = eventName.replace("on", "");
-		let nodeList = document.querySelectorAll("[" + eventName + "]");
+		let nodeList = window.sec_auto_query_all("[" + eventName + "]");
 		if (nodeList.length > 100) {
 			nodeList = nodeList.slice(0, 100);
 		}
 		nodeList = window.randArr(nodeList);
 		for (let node of nodeList) {
+			if (node.hasAttribute("sec_auto_skip")) {
+				continue;
+			}
 			await window.sleep(%f);
 			let evt = document.createEvent('CustomEvent');
 			evt.initCustomEvent(event, false, true, null);
@@ -381,7 +442,9 @@ const TriggerDom2EventJS = `
 			if (node.hasChildNodes) {
 				let index = parseInt(Math.random()*node.children.length,10);
 				try {
-					node.children[index].dispatchEvent(event);
+					if (!node.children[index].hasAttribute("sec_auto_skip")) {
+						node.children[index].dispatchEvent(event);
+					}
 				} catch(e) {}
 				let max = node.children.length>5?5:node.children.length;
 				for (let count=0;count<max;count++) {
@@ -391,12 +454,15 @@ const TriggerDom2EventJS = `
 			}
 		}
 	}
-	let nodes = document.querySelectorAll("[sec_auto_dom2_event_flag]");
+	let nodes = window.sec_auto_query_all("[sec_auto_dom2_event_flag]");
 	if (nodes.length > 200) {
 		nodes = nodes.slice(0, 200);
 	}
 	nodes = window.randArr(nodes);
 	for (let node of nodes) {
+		if (node.hasAttribute("sec_auto_skip")) {
+			continue;
+		}
 		let loop = 0;
 		await window.sleep(%f);
 		let event_name_list = node.getAttribute("sec_auto_dom2_event_flag").split("|");
@@ -423,11 +489,11 @@ const TriggerDom2EventJS = `
 
 const TriggerJavascriptProtocol = `
 (async function click_all_a_tag_javascript(){
-	let nodeListHref = document.querySelectorAll("[href]");
+	let nodeListHref = window.sec_auto_query_all("[href]");
 	nodeListHref = window.randArr(nodeListHref);
 	for (let node of nodeListHref) {
 		let attrValue = node.getAttribute("href");
-		if (attrValue.toLocaleLowerCase().startsWith("javascript:")) {
+		if (attrValue.toLocaleLowerCase().startsWith("javascript:") && !node.hasAttribute("sec_auto_skip")) {
 			await window.sleep(%f);
 			try {
 				eval(attrValue.substring(11));
@@ -435,11 +501,11 @@ const TriggerJavascriptProtocol = `
 			catch {}
 		}
 	}
-	let nodeListSrc = document.querySelectorAll("[src]");
+	let nodeListSrc = window.sec_auto_query_all("[src]");
 	nodeListSrc = window.randArr(nodeListSrc);
 	for (let node of nodeListSrc) {
 		let attrValue = node.getAttribute("src");
-		if (attrValue.toLocaleLowerCase().startsWith("javascript:")) {
+		if (attrValue.toLocaleLowerCase().startsWith("javascript:") && !node.hasAttribute("sec_auto_skip")) {
 			await window.sleep(%f);
 			try {
 				eval(attrValue.substring(11));
@@ -450,6 +516,377 @@ const TriggerJavascriptProtocol = `
 })()
 `
 
+// ExtractFormsJS 提取当前frame以及其中open shadow root中的全部表单,返回json字符串,每个frame分别执行
+const ExtractFormsJS = `
+(function sec_auto_extract_forms() {
+	let forms = [];
+	let frameUrl = window === window.top ? "" : location.href;
+	let formList = window.sec_auto_query_all ? window.sec_auto_query_all("form") : document.querySelectorAll("form");
+	for (let form of formList) {
+		let record = {
+			url: location.href,
+			frame: frameUrl,
+			action: form.getAttribute("action") || "",
+			method: (form.getAttribute("method") || "get").toLowerCase(),
+			enctype: (form.getAttribute("enctype") || "application/x-www-form-urlencoded").toLowerCase(),
+			fields: []
+		};
+		for (let el of form.elements) {
+			if (!el.name || el.disabled) {
+				continue;
+			}
+			let type = (el.type || el.tagName).toLowerCase();
+			if (type === "submit" || type === "button" || type === "reset" || type === "image") {
+				continue;
+			}
+			let field = {
+				name: el.name,
+				type: type,
+				default: el.defaultValue || el.getAttribute("value") || "",
+				value: el.value || "",
+				required: !!el.required,
+				checked: !!el.checked,
+				accept: el.getAttribute("accept") || "",
+				options: [],
+				constraints: {}
+			};
+			for (let attr of ["type", "pattern", "min", "max", "step", "minlength", "maxlength"]) {
+				if (el.hasAttribute(attr)) {
+					field.constraints[attr] = el.getAttribute(attr);
+				}
+			}
+			if (el.tagName === "SELECT") {
+				field.type = "select";
+				field.options = Array.from(el.options).map(o => o.value);
+				if (el.options.length > 0) {
+					field.default = el.options[0].value;
+				}
+			}
+			if (el.tagName === "TEXTAREA") {
+				field.type = "textarea";
+			}
+			record.fields.push(field);
+		}
+		forms.push(record);
+	}
+	return JSON.stringify(forms);
+})()
+`
+
+// DisableValidationJS 清除表单元素上的自定义校验错误,并关闭表单的浏览器校验
+const DisableValidationJS = `
+(function sec_auto_disable_validation() {
+	let formList = window.sec_auto_query_all ? window.sec_auto_query_all("form") : document.querySelectorAll("form");
+	for (let form of formList) {
+		form.noValidate = true;
+		for (let el of form.elements) {
+			try {
+				el.setCustomValidity("");
+			} catch(e) {}
+		}
+	}
+})()
+`
+
+// InfiniteScrollStepJS 向下滚动一屏并将可滚动容器滚动到底部,同时点击"加载更多"按钮,返回页面总高度和点击的按钮数
+const InfiniteScrollStepJS = `
+(function sec_auto_infinite_scroll_step(keywords) {
+	let height = document.documentElement.scrollHeight;
+	window.scrollBy(0, window.innerHeight);
+	for (let el of document.querySelectorAll("div, section, main, ul, ol")) {
+		if (el.scrollHeight > el.clientHeight + 10) {
+			let overflow = getComputedStyle(el).overflowY;
+			if (overflow === "auto" || overflow === "scroll") {
+				el.scrollTop = el.scrollHeight;
+				el.dispatchEvent(new Event("scroll"));
+				height += el.scrollHeight;
+			}
+		}
+	}
+	window.dispatchEvent(new Event("scroll"));
+	let clicked = 0;
+	for (let el of document.querySelectorAll("button, [role=button], a, div, span")) {
+		if (clicked >= 5) {
+			break;
+		}
+		let text = (el.innerText || "").trim().toLowerCase();
+		if (!text || text.length > 30 || el.children.length > 2 || el.hasAttribute("sec_auto_skip") || el.getClientRects().length === 0) {
+			continue;
+		}
+		if (el.tagName === "A") {
+			let href = (el.getAttribute("href") || "").trim().toLowerCase();
+			if (href && href !== "#" && !href.startsWith("javascript:")) {
+				continue;
+			}
+		}
+		if (keywords.some(k => text.includes(k))) {
+			try {
+				el.click();
+				clicked++;
+			} catch(e) {}
+		}
+	}
+	return JSON.stringify({height: height, clicked: clicked});
+})(%s)
+`
+
+// AttributeValuesJS 获取当前frame以及shadow root中全部元素的属性值,resolve为true时按照元素所在文档解析为绝对地址
+const AttributeValuesJS = `
+(function sec_auto_attribute_values(attr, resolve) {
+	let nodes = window.sec_auto_query_all ? window.sec_auto_query_all("[" + attr + "]") : Array.from(document.querySelectorAll("[" + attr + "]"));
+	return JSON.stringify(nodes.map(el => {
+		let value = el.getAttribute(attr);
+		if (resolve && value && !value.trim().toLowerCase().startsWith("javascript:")) {
+			try {
+				return new URL(value, el.baseURI).href;
+			} catch(e) {}
+		}
+		return value;
+	}));
+})(%s, %t)
+`
+
+// ElementSemanticsJS 获取当前frame以及shadow root中尚未检查过的可点击元素和表单的语义信息,返回json字符串
+const ElementSemanticsJS = `
+(function sec_auto_element_semantics() {
+	function is_submitter(el) {
+		return (el.tagName === "BUTTON" && (el.getAttribute("type") || "submit").toLowerCase() === "submit") || (el.tagName === "INPUT" && (el.type === "submit" || el.type === "image"));
+	}
+	let query = "a, button, summary, input[type=submit], input[type=button], input[type=image], form, [onclick], [role=button], [role=menuitem], [role=link], [sec_auto_dom2_event_flag]";
+	let nodes = window.sec_auto_query_all ? window.sec_auto_query_all(query) : Array.from(document.querySelectorAll(query));
+	window.sec_auto_guard_nodes = [];
+	let list = [];
+	for (let el of nodes) {
+		if (el.hasAttribute("sec_auto_guard_checked")) {
+			continue;
+		}
+		el.setAttribute("sec_auto_guard_checked", "");
+		// 只有表单本身和提交按钮需要检查表单的提交地址
+		let form = el.tagName === "FORM" ? el : (is_submitter(el) ? el.form : null);
+		window.sec_auto_guard_nodes.push(el);
+		list.push({
+			tag: el.tagName.toLowerCase(),
+			text: (el.innerText || "").trim().replace(/\s+/g, " ").substring(0, 100),
+			aria_label: el.getAttribute("aria-label") || "",
+			id: el.id || "",
+			class: el.getAttribute("class") || "",
+			title: el.getAttribute("title") || "",
+			value: el.tagName === "INPUT" || el.tagName === "BUTTON" ? (el.value || "") : "",
+			href: el.getAttribute("href") || "",
+			action: form ? (form.getAttribute("action") || "") : "",
+		});
+	}
+	return JSON.stringify(list);
+})()
+`
+
+// MarkSkipElementsJS 将危险元素标记为sec_auto_skip,危险的提交按钮所在的表单同样标记,参数为ElementSemanticsJS返回的下标和匹配的关键字
+const MarkSkipElementsJS = `
+(function sec_auto_mark_skip_elements(items) {
+	function is_submitter(el) {
+		return (el.tagName === "BUTTON" && (el.getAttribute("type") || "submit").toLowerCase() === "submit") || (el.tagName === "INPUT" && (el.type === "submit" || el.type === "image"));
+	}
+	let nodes = window.sec_auto_guard_nodes || [];
+	for (let item of items) {
+		let el = nodes[item.index];
+		if (!el) {
+			continue;
+		}
+		el.setAttribute("sec_auto_skip", item.keyword);
+		if (is_submitter(el) && el.form) {
+			el.form.setAttribute("sec_auto_skip", item.keyword);
+		}
+	}
+})(%s)
+`
+
+// NodeClickFunction 以节点为this执行的点击函数
+const NodeClickFunction = `function() {
+	try {
+		this.click();
+		return true;
+	} catch(e) {
+		return false;
+	}
+}`
+
+// ClientRouterJS 注入前端路由的查找和跳转函数,支持Vue Router、Nuxt、Next.js,其他框架通过history和hash跳转
+const ClientRouterJS = `
+(function sec_auto_client_router() {
+	if (window.sec_auto_client_router) {
+		return;
+	}
+	function find() {
+		if (window.$nuxt && window.$nuxt.$router) {
+			return window.$nuxt.$router;
+		}
+		for (let el of document.querySelectorAll("body, body > *, body > * > *")) {
+			if (el.__vue_app__ && el.__vue_app__.config.globalProperties.$router) {
+				return el.__vue_app__.config.globalProperties.$router;
+			}
+			if (el.__vue__ && el.__vue__.$router) {
+				return el.__vue__.$router;
+			}
+		}
+		return null;
+	}
+	function mode(router) {
+		if (router && (router.mode === "hash" || (router.options && router.options.history && String(router.options.history.base).includes("#")))) {
+			return "hash";
+		}
+		return location.hash.startsWith("#/") ? "hash" : "history";
+	}
+	window.sec_auto_client_router = {
+		routes: function () {
+			let router = find();
+			let routes = [];
+			function walk(list, base) {
+				for (let r of list || []) {
+					if (!r || typeof r.path !== "string") {
+						continue;
+					}
+					let p = r.path.startsWith("/") ? r.path : base.replace(/\/$/, "") + "/" + r.path;
+					routes.push(p);
+					walk(r.children, p);
+				}
+			}
+			if (router && router.getRoutes) {
+				routes = router.getRoutes().map(r => r.path);
+			} else if (router && router.options) {
+				walk(router.options.routes, "");
+			}
+			if (window.__BUILD_MANIFEST) {
+				routes = routes.concat(Object.keys(window.__BUILD_MANIFEST).filter(k => k.startsWith("/")));
+			}
+			return JSON.stringify({mode: mode(router), routes: routes});
+		},
+		navigate: async function (route) {
+			let router = find();
+			try {
+				if (router) {
+					await router.push(route);
+					return "vue";
+				}
+				if (window.next && window.next.router) {
+					await window.next.router.push(route);
+					return "next";
+				}
+			} catch(e) {}
+			if (mode(router) === "hash") {
+				location.hash = "#" + route;
+				return "hash";
+			}
+			window.__sec_auto_original_push_state.call(window.history, null, "", route);
+			window.dispatchEvent(new PopStateEvent("popstate", {state: null}));
+			return "history";
+		}
+	};
+})()
+`
+
+// ClientRoutesJS 获取运行时路由器中的路由表和路由模式
+const ClientRoutesJS = `window.sec_auto_client_router ? window.sec_auto_client_router.routes() : ""`
+
+// ClientRouteNavigateJS 通过应用自身的路由跳转到指定路由
+const ClientRouteNavigateJS = `window.sec_auto_client_router && window.sec_auto_client_router.navigate(%s)`
+
+// DOMStateHashJS 计算页面可见元素结构和文本的hash,文本中的数字统一替换,避免时间、计数导致状态不断变化
+const DOMStateHashJS = `
+(function sec_auto_dom_state_hash() {
+	function fnv(str, seed) {
+		let h = seed >>> 0;
+		for (let i = 0; i < str.length; i++) {
+			h ^= str.charCodeAt(i);
+			h = Math.imul(h, 16777619) >>> 0;
+		}
+		return ("00000000" + h.toString(16)).slice(-8);
+	}
+	let root = document.body || document.documentElement;
+	if (!root) {
+		return "";
+	}
+	let parts = [];
+	let walker = document.createTreeWalker(root, NodeFilter.SHOW_ELEMENT | NodeFilter.SHOW_TEXT);
+	for (let count = 0; walker.nextNode() && count < 20000; count++) {
+		let node = walker.currentNode;
+		if (node.nodeType === 3) {
+			let text = node.nodeValue.trim();
+			if (text && node.parentElement && node.parentElement.getClientRects().length > 0) {
+				parts.push("#" + text.substring(0, 32).replace(/\d+/g, "0"));
+			}
+			continue;
+		}
+		if (node.getClientRects().length === 0) {
+			continue;
+		}
+		parts.push(node.tagName + "." + node.id + "." + (node.getAttribute("class") || ""));
+	}
+	let signature = parts.join("|");
+	return fnv(signature, 2166136261) + fnv(signature, 16777619);
+})()
+`
+
+// ListActionsJS 列出当前状态下可见的可交互元素,返回包含css选择器和文本描述的json字符串
+const ListActionsJS = `
+(function sec_auto_list_actions(limit) {
+	function selectorOf(el) {
+		let parts = [];
+		while (el && el.nodeType === 1 && el !== document.documentElement) {
+			if (el.id && document.querySelectorAll("#" + CSS.escape(el.id)).length === 1) {
+				parts.unshift("#" + CSS.escape(el.id));
+				break;
+			}
+			let index = 1;
+			for (let sibling = el.previousElementSibling; sibling; sibling = sibling.previousElementSibling) {
+				if (sibling.tagName === el.tagName) {
+					index++;
+				}
+			}
+			parts.unshift(el.tagName.toLowerCase() + ":nth-of-type(" + index + ")");
+			el = el.parentElement;
+		}
+		return parts.join(" > ");
+	}
+	let query = "[sec_auto_dom2_event_flag], [onclick], button, summary, [role=button], [role=tab], [role=menuitem], [role=link], [data-toggle], [data-bs-toggle], a[href^='javascript:'], a[href^='#']";
+	let actions = [];
+	let seen = new Set();
+	for (let el of document.querySelectorAll(query)) {
+		if (actions.length >= limit) {
+			break;
+		}
+		if (el.disabled || el.type === "reset" || el.hasAttribute("sec_auto_skip") || el.getClientRects().length === 0) {
+			continue;
+		}
+		let selector = selectorOf(el);
+		if (seen.has(selector)) {
+			continue;
+		}
+		seen.add(selector);
+		let label = (el.innerText || el.getAttribute("aria-label") || el.getAttribute("title") || el.value || "").trim().replace(/\s+/g, " ").substring(0, 50);
+		actions.push({selector: selector, label: label});
+	}
+	return JSON.stringify(actions);
+})(%d)
+`
+
+// PerformActionJS 按照css选择器找到元素并模拟一次完整的鼠标点击
+const PerformActionJS = `
+(function sec_auto_perform_action(selector) {
+	let el = document.querySelector(selector);
+	if (!el || el.hasAttribute("sec_auto_skip")) {
+		return false;
+	}
+	try {
+		el.scrollIntoView({block: "center"});
+		for (let name of ["mouseover", "mousedown", "mouseup"]) {
+			el.dispatchEvent(new MouseEvent(name, {bubbles: true, cancelable: true, view: window}));
+		}
+		el.click();
+	} catch(e) {}
+	return true;
+})(%s)
+`
+
 const FormNodeClickJS = `
 (function(a) {
 	try {
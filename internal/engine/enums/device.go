@@ -0,0 +1,85 @@
+package enums
+
+// DeviceProfile 设备模拟配置,每个tab页按照该配置设置UA、视口、缩放、触摸以及navigator属性
+type DeviceProfile struct {
+	Name                string  `json:"name"`                 // 配置名称
+	UserAgent           string  `json:"user_agent"`           // 浏览器UA
+	Platform            string  `json:"platform"`             // navigator.platform
+	Width               int64   `json:"width"`                // 视口宽度
+	Height              int64   `json:"height"`               // 视口高度
+	DeviceScaleFactor   float64 `json:"device_scale_factor"`  // 设备像素比
+	Mobile              bool    `json:"mobile"`               // 是否为移动设备
+	Touch               bool    `json:"touch"`                // 是否开启触摸模拟
+	MaxTouchPoints      int64   `json:"max_touch_points"`     // navigator.maxTouchPoints
+	Vendor              string  `json:"vendor"`               // navigator.vendor
+	HardwareConcurrency int64   `json:"hardware_concurrency"` // navigator.hardwareConcurrency
+	DeviceMemory        int64   `json:"device_memory"`        // navigator.deviceMemory
+}
+
+const DefaultDeviceProfile = "desktop"
+
+// DefaultDeviceProfiles 内置的设备配置
+var DefaultDeviceProfiles = map[string]DeviceProfile{
+	"desktop": {
+		Name:                "desktop",
+		UserAgent:           DefaultUA,
+		Platform:            "Win32",
+		Width:               1920,
+		Height:              1080,
+		DeviceScaleFactor:   1,
+		Vendor:              "Google Inc.",
+		HardwareConcurrency: 8,
+		DeviceMemory:        8,
+	},
+	"macbook": {
+		Name:                "macbook",
+		UserAgent:           "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/111.0.0.0 Safari/537.36",
+		Platform:            "MacIntel",
+		Width:               1440,
+		Height:              900,
+		DeviceScaleFactor:   2,
+		Vendor:              "Google Inc.",
+		HardwareConcurrency: 8,
+		DeviceMemory:        8,
+	},
+	"iphone": {
+		Name:                "iphone",
+		UserAgent:           "Mozilla/5.0 (iPhone; CPU iPhone OS 16_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.3 Mobile/15E148 Safari/604.1",
+		Platform:            "iPhone",
+		Width:               390,
+		Height:              844,
+		DeviceScaleFactor:   3,
+		Mobile:              true,
+		Touch:               true,
+		MaxTouchPoints:      5,
+		Vendor:              "Apple Computer, Inc.",
+		HardwareConcurrency: 4,
+	},
+	"ipad": {
+		Name:                "ipad",
+		UserAgent:           "Mozilla/5.0 (iPad; CPU OS 16_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.3 Mobile/15E148 Safari/604.1",
+		Platform:            "iPad",
+		Width:               820,
+		Height:              1180,
+		DeviceScaleFactor:   2,
+		Mobile:              true,
+		Touch:               true,
+		MaxTouchPoints:      5,
+		Vendor:              "Apple Computer, Inc.",
+		HardwareConcurrency: 4,
+	},
+	"android": {
+		Name:                "android",
+		UserAgent:           "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/111.0.0.0 Mobile Safari/537.36",
+		Platform:            "Linux armv8l",
+		Width:               412,
+		Height:              915,
+		DeviceScaleFactor:   2.625,
+		Mobile:              true,
+		Touch:               true,
+		MaxTouchPoints:      5,
+		Vendor:              "Google Inc.",
+		HardwareConcurrency: 8,
+		DeviceMemory:        8,
+	},
+}
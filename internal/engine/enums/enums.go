@@ -2,7 +2,7 @@ package enums
 
 import "regexp"
 
-var SupportContentType = []string{JSON, URLENCODED}
+var SupportContentType = []string{JSON, URLENCODED, MULTIPART}
 
 const (
 	JSON       = "application/json"
@@ -11,24 +11,35 @@ const (
 )
 
 const (
-	FromTarget      = "Target"     //初始输入的目标
-	FromNavigation  = "Navigation" //页面导航请求
-	FromXHR         = "XHR"        //ajax异步请求
-	FromDOM         = "DOM"        //dom解析出来的请求
-	FromJSFile      = "JavaScript" //JS脚本中解析
-	FromFuzz        = "PathFuzz"   //初始path fuzz
-	FromRobots      = "robots.txt" //robots.txt
-	FromSitemap     = "sitemap.xml"
-	FromComment     = "Comment"     //页面中的注释
-	FromWebSocket   = "WebSocket"   // websocket请求
-	FromEventSource = "EventSource" // 事件源
-	FromFetch       = "Fetch"
-	FromHistoryAPI  = "HistoryAPI"
-	FromOpenWindow  = "OpenWindow"
-	FromHashChange  = "HashChange"
-	FromStaticRes   = "StaticResource"
-	FromStaticRegex = "StaticRegex"
-	FromHeader      = "Header" // 响应头获取
+	FromTarget        = "Target"     //初始输入的目标
+	FromNavigation    = "Navigation" //页面导航请求
+	FromXHR           = "XHR"        //ajax异步请求
+	FromDOM           = "DOM"        //dom解析出来的请求
+	FromJSFile        = "JavaScript" //JS脚本中解析
+	FromFuzz          = "PathFuzz"   //初始path fuzz
+	FromRobots        = "robots.txt" //robots.txt
+	FromSitemap       = "sitemap.xml"
+	FromComment       = "Comment"     //页面中的注释
+	FromWebSocket     = "WebSocket"   // websocket请求
+	FromEventSource   = "EventSource" // 事件源
+	FromFetch         = "Fetch"
+	FromHistoryAPI    = "HistoryAPI"
+	FromOpenWindow    = "OpenWindow"
+	FromHashChange    = "HashChange"
+	FromStaticRes     = "StaticResource"
+	FromStaticRegex   = "StaticRegex"
+	FromHeader        = "Header"        // 响应头获取
+	FromForm          = "Form"          // 解析表单合成的请求
+	FromUpload        = "Upload"        // 文件上传请求
+	FromClientRoute   = "ClientRoute"   // 前端路由
+	FromSourceMap     = "SourceMap"     // source map还原的源码
+	FromServiceWorker = "ServiceWorker" // service worker脚本
+	FromWorker        = "Worker"        // worker脚本及其importScripts
+	FromPrecache      = "Precache"      // service worker的预缓存列表
+	FromManifest      = "Manifest"      // web app manifest
+	FromCSP           = "CSP"           // 内容安全策略
+	FromCORS          = "CORS"          // 跨域资源共享允许的源
+	FromReportTo      = "ReportTo"      // 报告上报的地址
 )
 
 // 请求方法
@@ -39,6 +50,7 @@ const (
 	DELETE  = "DELETE"
 	HEAD    = "HEAD"
 	OPTIONS = "OPTIONS"
+	PATCH   = "PATCH"
 )
 
 var ChineseRegex = regexp.MustCompile("[\u4e00-\u9fa5]+")
@@ -80,9 +92,10 @@ const (
 )
 
 const (
-	MaxParentPathCount         = 32 // 相对于上一级目录，本级path目录的数量修正最大值
-	MaxParamKeySingleCount     = 8  // 某个URL参数名重复修正最大值
-	MaxParamKeyAllCount        = 10 // 本轮所有URL中某个参数名的重复修正最大值
-	MaxPathParamEmptyCount     = 10 // 某个path下的参数值为空，参数名个数修正最大值
-	MaxPathParamKeySymbolCount = 5  // 某个Path下的某个参数的标记数量超过此值，则该参数被全局标记
+	MaxParentPathCount         = 32     // 相对于上一级目录，本级path目录的数量修正最大值
+	MaxParamKeySingleCount     = 8      // 某个URL参数名重复修正最大值
+	MaxParamKeyAllCount        = 10     // 本轮所有URL中某个参数名的重复修正最大值
+	MaxPathParamEmptyCount     = 10     // 某个path下的参数值为空，参数名个数修正最大值
+	MaxPathParamKeySymbolCount = 5      // 某个Path下的某个参数的标记数量超过此值，则该参数被全局标记
+	FilterStatMaxKeys          = 200000 // 智能过滤最多记录的统计项数量,超过后不再新建统计项
 )
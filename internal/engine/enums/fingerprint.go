@@ -0,0 +1,117 @@
+package enums
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Fingerprint 浏览器指纹配置,UA、平台、语言、Accept-Language和时区均由此生成,保证彼此一致
+type Fingerprint struct {
+	UserAgent string   // 浏览器UA
+	Platform  string   // navigator.platform
+	Mobile    bool     // 是否为移动设备
+	Languages []string // navigator.languages,第一个为navigator.language
+	Timezone  string   // IANA时区,例如 Asia/Shanghai
+}
+
+var chromeVersionRegex = regexp.MustCompile(`Chrome/(\d+)[\d.]*`)
+
+// NewFingerprint 根据设备配置、语言和时区生成指纹,缺省值使用默认配置
+func NewFingerprint(device DeviceProfile, languages []string, timezone string) Fingerprint {
+	fp := Fingerprint{
+		UserAgent: device.UserAgent,
+		Platform:  device.Platform,
+		Mobile:    device.Mobile,
+		Languages: languages,
+		Timezone:  timezone,
+	}
+	if fp.UserAgent == "" {
+		fp.UserAgent = DefaultUA
+	}
+	if fp.Platform == "" {
+		fp.Platform = PlatformFromUserAgent(fp.UserAgent)
+	}
+	if len(fp.Languages) == 0 {
+		fp.Languages = DefaultLanguages
+	}
+	if fp.Timezone == "" {
+		fp.Timezone = DefaultTimezone
+	}
+	return fp
+}
+
+// PlatformFromUserAgent 根据UA推断navigator.platform
+func PlatformFromUserAgent(ua string) string {
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		return "iPhone"
+	case strings.Contains(ua, "iPad"):
+		return "iPad"
+	case strings.Contains(ua, "Android"):
+		return "Linux armv8l"
+	case strings.Contains(ua, "Windows"):
+		return "Win32"
+	case strings.Contains(ua, "Macintosh"):
+		return "MacIntel"
+	case strings.Contains(ua, "Linux"):
+		return "Linux x86_64"
+	}
+	return "Win32"
+}
+
+// ClientHintPlatform 返回Sec-CH-UA-Platform使用的平台名称
+func (fp Fingerprint) ClientHintPlatform() string {
+	switch {
+	case strings.Contains(fp.UserAgent, "Android"):
+		return "Android"
+	case strings.HasPrefix(fp.Platform, "iP"):
+		return "iOS"
+	case strings.HasPrefix(fp.Platform, "Win"):
+		return "Windows"
+	case strings.HasPrefix(fp.Platform, "Mac"):
+		return "macOS"
+	}
+	return "Linux"
+}
+
+// ChromeMajorVersion 返回UA中Chrome的主版本号,非Chrome的UA返回空字符串
+func (fp Fingerprint) ChromeMajorVersion() string {
+	match := chromeVersionRegex.FindStringSubmatch(fp.UserAgent)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
+
+// AcceptLanguage 按照语言列表生成Accept-Language请求头,例如 zh-CN,zh;q=0.9,en;q=0.8
+func (fp Fingerprint) AcceptLanguage() string {
+	var parts []string
+	for i, language := range fp.Languages {
+		q := 10 - i
+		if i == 0 {
+			parts = append(parts, language)
+		} else if q > 0 {
+			parts = append(parts, fmt.Sprintf("%s;q=0.%d", language, q))
+		} else {
+			parts = append(parts, language+";q=0.1")
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Locale 返回ICU格式的区域设置,例如 zh_CN
+func (fp Fingerprint) Locale() string {
+	if len(fp.Languages) == 0 {
+		return ""
+	}
+	return strings.Replace(fp.Languages[0], "-", "_", 1)
+}
+
+// InitJS 生成注入页面的navigator属性脚本
+func (fp Fingerprint) InitJS() string {
+	languages, _ := json.Marshal(fp.Languages)
+	platform, _ := json.Marshal(fp.Platform)
+	return fmt.Sprintf(NavigatorInitJS, platform, languages)
+}
@@ -0,0 +1,30 @@
+package enums
+
+import "testing"
+
+func TestFingerprint_AcceptLanguage(t *testing.T) {
+	fp := NewFingerprint(DeviceProfile{}, []string{"en-US", "en", "fr"}, "")
+	if got := fp.AcceptLanguage(); got != "en-US,en;q=0.9,fr;q=0.8" {
+		t.Fatalf("unexpected Accept-Language: %s", got)
+	}
+	if fp.Locale() != "en_US" {
+		t.Fatalf("unexpected locale: %s", fp.Locale())
+	}
+	if fp.Timezone != DefaultTimezone {
+		t.Fatalf("unexpected timezone: %s", fp.Timezone)
+	}
+}
+
+func TestFingerprint_Platform(t *testing.T) {
+	fp := NewFingerprint(DefaultDeviceProfiles["android"], nil, "Europe/Paris")
+	if fp.ClientHintPlatform() != "Android" || !fp.Mobile {
+		t.Fatalf("unexpected platform: %s", fp.ClientHintPlatform())
+	}
+	fp = NewFingerprint(DeviceProfile{UserAgent: DefaultDeviceProfiles["macbook"].UserAgent}, nil, "")
+	if fp.Platform != "MacIntel" || fp.ClientHintPlatform() != "macOS" {
+		t.Fatalf("unexpected platform: %s", fp.Platform)
+	}
+	if fp.ChromeMajorVersion() != "111" {
+		t.Fatalf("unexpected chrome version: %s", fp.ChromeMajorVersion())
+	}
+}
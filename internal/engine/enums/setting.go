@@ -3,7 +3,8 @@ package enums
 import "time"
 
 const (
-	DefaultUA               = "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/79.0.3945.0 Safari/537.36"
+	DefaultUA               = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/111.0.0.0 Safari/537.36"
+	DefaultTimezone         = "Asia/Shanghai"
 	MaxTabsCount            = 10
 	TabRunTimeout           = 15 * time.Second
 	DefaultInputText        = "Universe"
@@ -20,10 +21,17 @@ const (
 
 // 事件触发模式
 const (
-	EventTriggerAsync = "async"
-	EventTriggerSync  = "sync"
+	EventTriggerAsync   = "async"
+	EventTriggerSync    = "sync"
+	EventTriggerTrusted = "trusted" // 通过CDP Input域顺序派发可信的鼠标和键盘事件
 )
 
+// TrustedEventMaxNodes 可信事件模式下每类事件最多触发的元素数量
+const TrustedEventMaxNodes = 200
+
+// InlineEventNames 需要触发的内联事件属性
+var InlineEventNames = []string{"onabort", "onblur", "onchange", "onclick", "ondblclick", "onerror", "onfocus", "onkeydown", "onkeypress", "onkeyup", "onload", "onmousedown", "onmousemove", "onmouseout", "onmouseover", "onmouseup", "onreset", "onresize", "onselect", "onsubmit", "onunload"}
+
 var DefaultInputTextMap = map[string]map[string]interface{}{
 	"mail": {
 		"keyword": []string{"mail"},
@@ -69,6 +77,32 @@ var DefaultInputTextMap = map[string]map[string]interface{}{
 
 var DefaultIgnoreKeywords = []string{"logout", "quit", "exit"}
 
+// WebSocket消息捕获
+const (
+	WebSocketMaxFrames  = 100  // 每个连接默认最多记录的消息帧数
+	WebSocketMaxPayload = 4096 // 每条消息最多记录的字节数
+	WebSocketMaxSchemas = 50   // 每个连接最多记录的消息结构数
+)
+
+// DefaultBodyStoreMaxSize 响应体存储默认的大小限制
+var DefaultBodyStoreMaxSize = map[string]int{
+	"*":       5 * 1024 * 1024,
+	"image/*": 1024 * 1024,
+	"font/*":  1024 * 1024,
+}
+
+// DefaultBodyStoreDenyMime 响应体存储默认不存储的MIME类型
+var DefaultBodyStoreDenyMime = []string{"video/*", "audio/*"}
+
+// DefaultDangerKeywords 默认的危险操作关键字,元素的文本、aria-label、id、class、表单提交地址等匹配上之后不会被点击或提交
+var DefaultDangerKeywords = []string{
+	"delete", "remove", "destroy", "erase", "purge", "wipe", "truncate", "reset password",
+	"deactivate", "disable account", "close account", "terminate", "unsubscribe", "revoke", "logout", "log out", "sign out", "signout",
+	"删除", "移除", "清空", "销毁", "注销", "停用", "退出", "解绑", "重置密码",
+}
+
+var DefaultLanguages = []string{"zh-CN", "zh"}
+
 const DefaultFuzzDict = "11/123/2017/2018/message/mis/model/abstract/account/act/action" +
 	"/activity/ad/address/ajax/alarm/api/app/ar/attachment/auth/authority/award/back/backup/bak/base" +
 	"/bbs/bbs1/cms/bd/gallery/game/gift/gold/bg/bin/blacklist/blog/bootstrap/brand/build/cache/caches" +
@@ -88,3 +122,62 @@ const DefaultFuzzDict = "11/123/2017/2018/message/mis/model/abstract/account/act
 	"/templates/test/tests/ticket/tmp/token/tool/tools/top/tpl/txt/upload/uploadify/uploads/url/user" +
 	"/util/v1/v2/vendor/view/views/web/weixin/widgets/wm/wordpress/workspace/ws/www/www2/wwwroot/zone" +
 	"/admin/admin_bak/mobile/m/js"
+
+// 单页应用状态探索
+const (
+	ExploreMaxActions      = 50                     // 每个tab页默认最多执行的动作数
+	ExploreMaxStateActions = 30                     // 每个状态最多尝试的动作数
+	ExploreSettleDelay     = 500 * time.Millisecond // 动作执行后等待页面渲染的时间
+)
+
+// 无限滚动和懒加载
+const (
+	ScrollMaxCount    = 20                     // 默认最多滚动的次数
+	ScrollInterval    = 500 * time.Millisecond // 每次滚动后等待内容加载的时间
+	ScrollStableCount = 2                      // 页面高度连续多少次不再增长时停止滚动
+)
+
+// DefaultLoadMoreKeywords "加载更多"按钮的文本关键字
+var DefaultLoadMoreKeywords = []string{"load more", "show more", "view more", "see more", "more results", "加载更多", "查看更多", "显示更多", "点击加载", "更多内容"}
+
+// 前端路由
+const (
+	ClientRouteMaxCount    = 50   // 每个tab页最多访问的前端路由数
+	ClientRouteObjectRange = 300  // 判断路由对象时在path前后查找的字符数
+	ClientRouteParentRange = 5000 // 查找子路由所在的children数组时向前查找的字符数
+)
+
+// 渲染后DOM归档
+const (
+	ArchiveMaxSize       = 5 * 1024 * 1024 // 单个归档文件的默认最大字节数
+	ArchiveIndexFileName = "index.jsonl"   // 归档索引文件名
+)
+
+// DefaultFilterFalsePositive 布隆过滤器默认的误判率
+const DefaultFilterFalsePositive = 0.0001
+
+// FilterReportMaxSamples 过滤报告中每个原因最多记录的请求数量
+const FilterReportMaxSamples = 1000
+
+// 相似页面检测
+const (
+	NearDuplicateMaxPages = 5 // 同一个地址模板下几乎相同的页面数量阈值
+	NearDuplicateDistance = 4 // simhash的最大汉明距离
+)
+
+// SourceMapMaxSize source map文件的最大字节数
+const SourceMapMaxSize = 50 * 1024 * 1024
+
+// ChunkedBodyMaxSize 没有Content-Length且Range没有上限时最多读取的响应体字节数,超过source map的限制,由调用方判断是否过大
+const ChunkedBodyMaxSize = SourceMapMaxSize + 1
+
+// SecretRegex 在还原的源码中匹配的敏感信息
+var SecretRegex = map[string]string{
+	"AWSAccessKey":  `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	"GoogleAPIKey":  `\bAIza[0-9A-Za-z_\-]{35}\b`,
+	"GitHubToken":   `\bgh[pousr]_[0-9A-Za-z]{36}\b`,
+	"SlackToken":    `\bxox[abprs]-[0-9A-Za-z\-]{10,}\b`,
+	"PrivateKey":    `-----BEGIN (?:RSA |EC |DSA |OPENSSH )?PRIVATE KEY-----`,
+	"JWT":           `\beyJ[0-9A-Za-z_\-]{8,}\.eyJ[0-9A-Za-z_\-]{8,}\.[0-9A-Za-z_\-]{8,}`,
+	"GenericSecret": `(?i)\b(?:api_?key|app_?secret|client_?secret|secret_?key|access_?token|password)["']?\s*[:=]\s*["'][^"'\s]{8,}["']`,
+}
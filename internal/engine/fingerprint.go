@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"context"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// EmulateFingerprint 按照tab页的指纹配置设置UA、平台、语言和时区,需要在导航之前执行
+func (tab *Tab) EmulateFingerprint() chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		fp := tab.config.Fingerprint
+		if fp.UserAgent == "" {
+			return nil
+		}
+		override := emulation.SetUserAgentOverride(fp.UserAgent).
+			WithPlatform(fp.Platform).
+			WithAcceptLanguage(fp.AcceptLanguage())
+		if version := fp.ChromeMajorVersion(); version != "" {
+			// 客户端提示头也需要和UA保持一致
+			override = override.WithUserAgentMetadata(&emulation.UserAgentMetadata{
+				Brands: []*emulation.UserAgentBrandVersion{
+					{Brand: "Chromium", Version: version},
+					{Brand: "Google Chrome", Version: version},
+					{Brand: "Not:A-Brand", Version: "99"},
+				},
+				Platform: fp.ClientHintPlatform(),
+				Mobile:   fp.Mobile,
+			})
+		}
+		if err := override.Do(ctx); err != nil {
+			return err
+		}
+		if fp.Timezone != "" {
+			if err := emulation.SetTimezoneOverride(fp.Timezone).Do(ctx); err != nil {
+				return err
+			}
+		}
+		if locale := fp.Locale(); locale != "" {
+			_ = emulation.SetLocaleOverride().WithLocale(locale).Do(ctx)
+		}
+		_, err := page.AddScriptToEvaluateOnNewDocument(fp.InitJS()).Do(ctx)
+		return err
+	}
+}
@@ -0,0 +1,269 @@
+package engine
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 这里根据HTML5的表单约束生成可以通过浏览器校验的值
+
+// ConstraintAttributes 会影响表单校验的属性
+var ConstraintAttributes = []string{"type", "pattern", "min", "max", "step", "minlength", "maxlength"}
+
+// constraintTypeValues 特殊类型输入框在没有min、max约束时使用的值
+var constraintTypeValues = map[string]string{
+	"date":           "2023-01-01",
+	"datetime-local": "2023-01-01T10:00",
+	"month":          "2023-01",
+	"week":           "2023-W01",
+	"time":           "10:00",
+	"color":          "#ff0000",
+	"url":            "https://universe.nice.cn/",
+	"email":          "universe@gmail.com",
+}
+
+// HasConstraint 判断属性中是否存在需要满足的约束
+func HasConstraint(attrs map[string]string) bool {
+	if _, ok := constraintTypeValues[strings.ToLower(attrs["type"])]; ok {
+		return true
+	}
+	if t := strings.ToLower(attrs["type"]); t == "number" || t == "range" {
+		return true
+	}
+	for _, name := range ConstraintAttributes[1:] {
+		if attrs[name] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateConstraintValue 根据type、pattern、min、max、step、minlength、maxlength生成满足约束的值,
+// fallback为没有类型约束时的候选值,生成失败时返回false
+func GenerateConstraintValue(attrs map[string]string, fallback string) (string, bool) {
+	inputType := strings.ToLower(attrs["type"])
+	switch inputType {
+	case "number", "range":
+		return generateNumberValue(attrs, inputType)
+	case "date", "datetime-local", "month", "week", "time":
+		return generateDateValue(attrs, inputType), true
+	}
+	if pattern := attrs["pattern"]; pattern != "" {
+		return generatePatternValue(pattern, attrs)
+	}
+	value := fallback
+	if v, ok := constraintTypeValues[inputType]; ok && !matchInputType(fallback, inputType) {
+		value = v
+	}
+	return fitLength(value, attrs), true
+}
+
+// matchInputType 判断候选值是否满足url、email、color类型的格式
+func matchInputType(value string, inputType string) bool {
+	switch inputType {
+	case "url":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	case "email":
+		return strings.Count(value, "@") == 1 && !strings.HasPrefix(value, "@") && !strings.HasSuffix(value, "@")
+	case "color":
+		return regexp.MustCompile(`^#[0-9a-fA-F]{6}$`).MatchString(value)
+	}
+	return false
+}
+
+// generateNumberValue 生成满足min、max、step的数值,优先使用min,其次为范围内靠近默认值的数
+func generateNumberValue(attrs map[string]string, inputType string) (string, bool) {
+	minValue, hasMin := parseFloatAttr(attrs["min"])
+	maxValue, hasMax := parseFloatAttr(attrs["max"])
+	if inputType == "range" {
+		// range默认的范围为0到100
+		if !hasMin {
+			minValue, hasMin = 0, true
+		}
+		if !hasMax {
+			maxValue, hasMax = 100, true
+		}
+	}
+	if hasMin && hasMax && minValue > maxValue {
+		return "", false
+	}
+	value := 10.0
+	if hasMin && hasMax {
+		value = minValue + (maxValue-minValue)/2
+	} else if hasMin {
+		value = minValue
+	} else if hasMax && value > maxValue {
+		value = maxValue
+	}
+	// 按照step对齐,step的基准为min,没有min时为0
+	step, hasStep := parseFloatAttr(attrs["step"])
+	if !hasStep && attrs["step"] != "any" {
+		step, hasStep = 1, true
+	}
+	if hasStep && step > 0 {
+		base := 0.0
+		if hasMin {
+			base = minValue
+		}
+		value = base + math.Floor((value-base)/step)*step
+		if hasMin && value < minValue {
+			value += step
+		}
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), true
+}
+
+// generateDateValue 生成日期类的值,存在min时使用min,超过max时使用max
+func generateDateValue(attrs map[string]string, inputType string) string {
+	value := constraintTypeValues[inputType]
+	if attrs["min"] != "" && attrs["min"] > value {
+		return attrs["min"]
+	}
+	if attrs["max"] != "" && attrs["max"] < value {
+		return attrs["max"]
+	}
+	return value
+}
+
+// generatePatternValue 根据pattern正则生成字符串,逐步增加重复次数以满足长度约束
+func generatePatternValue(pattern string, attrs map[string]string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	matcher, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return "", false
+	}
+	re = re.Simplify()
+	for extra := 0; extra < 64; extra++ {
+		var sb strings.Builder
+		generateFromRegexp(&sb, re, extra)
+		value := sb.String()
+		if matcher.MatchString(value) && lengthFits(value, attrs) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// generateFromRegexp 遍历正则的语法树生成字符串,extra为每个重复项额外的重复次数
+func generateFromRegexp(sb *strings.Builder, re *syntax.Regexp, extra int) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			sb.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		sb.WriteRune(pickClassRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteRune('a')
+	case syntax.OpCapture:
+		generateFromRegexp(sb, re.Sub[0], extra)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			generateFromRegexp(sb, sub, extra)
+		}
+	case syntax.OpAlternate:
+		generateFromRegexp(sb, re.Sub[0], extra)
+	case syntax.OpStar, syntax.OpQuest, syntax.OpPlus, syntax.OpRepeat:
+		minCount, maxCount := 0, -1
+		switch re.Op {
+		case syntax.OpQuest:
+			maxCount = 1
+		case syntax.OpPlus:
+			minCount = 1
+		case syntax.OpRepeat:
+			minCount, maxCount = re.Min, re.Max
+		}
+		count := minCount + extra
+		if maxCount >= 0 && count > maxCount {
+			count = maxCount
+		}
+		for i := 0; i < count; i++ {
+			generateFromRegexp(sb, re.Sub[0], extra)
+		}
+	}
+}
+
+// pickClassRune 从字符集合中选择一个字符,优先选择字母和数字
+func pickClassRune(ranges []rune) rune {
+	for _, preferred := range []rune{'a', 'A', '1'} {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= preferred && preferred <= ranges[i+1] {
+				return preferred
+			}
+		}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r < ranges[i]+256; r++ {
+			if unicode.IsPrint(r) && !unicode.IsSpace(r) {
+				return r
+			}
+		}
+	}
+	if len(ranges) > 0 {
+		return ranges[0]
+	}
+	return 'a'
+}
+
+// fitLength 截断或者补齐字符串以满足minlength、maxlength
+func fitLength(value string, attrs map[string]string) string {
+	runes := []rune(value)
+	if maxLength, err := strconv.Atoi(attrs["maxlength"]); err == nil && maxLength >= 0 && len(runes) > maxLength {
+		runes = runes[:maxLength]
+	}
+	if minLength, err := strconv.Atoi(attrs["minlength"]); err == nil && len(runes) < minLength {
+		runes = append(runes, []rune(strings.Repeat("a", minLength-len(runes)))...)
+	}
+	return string(runes)
+}
+
+func lengthFits(value string, attrs map[string]string) bool {
+	length := len([]rune(value))
+	if maxLength, err := strconv.Atoi(attrs["maxlength"]); err == nil && maxLength >= 0 && length > maxLength {
+		return false
+	}
+	if minLength, err := strconv.Atoi(attrs["minlength"]); err == nil && length < minLength {
+		return false
+	}
+	return true
+}
+
+func parseFloatAttr(value string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+// GetConstraintInputText 获取满足约束的输入值,没有约束时使用关键字匹配
+func (f *FillForm) GetConstraintInputText(attrs map[string]string, name string) string {
+	keywordValue := f.GetMatchInputText(name)
+	if !HasConstraint(attrs) {
+		return keywordValue
+	}
+	if value, ok := GenerateConstraintValue(attrs, keywordValue); ok {
+		return value
+	}
+	return keywordValue
+}
+
+// nodeConstraintAttributes 获取节点上与约束相关的属性
+func nodeConstraintAttributes(attribute func(string) string) map[string]string {
+	attrs := map[string]string{}
+	for _, name := range ConstraintAttributes {
+		if value := attribute(name); value != "" {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGenerateConstraintValue_Pattern(t *testing.T) {
+	for _, attrs := range []map[string]string{
+		{"pattern": `[0-9]{6}`},
+		{"pattern": `1[3-9]\d{9}`},
+		{"pattern": `[A-Z]{2}-\d+`, "minlength": "6"},
+		{"pattern": `[a-z]+@[a-z]+\.com`},
+		{"pattern": `(abc|def)x?`, "maxlength": "3"},
+	} {
+		value, ok := GenerateConstraintValue(attrs, "Universe")
+		if !ok {
+			t.Fatalf("pattern %q: generate failed", attrs["pattern"])
+		}
+		if !regexp.MustCompile(`^(?:`+attrs["pattern"]+`)$`).MatchString(value) || !lengthFits(value, attrs) {
+			t.Fatalf("pattern %q: invalid value %q", attrs["pattern"], value)
+		}
+	}
+	if _, ok := GenerateConstraintValue(map[string]string{"pattern": `[0-9]{6}`, "maxlength": "3"}, ""); ok {
+		t.Fatal("expected unsatisfiable constraint to fail")
+	}
+}
+
+func TestGenerateConstraintValue_Number(t *testing.T) {
+	for _, item := range []struct {
+		attrs map[string]string
+		value string
+	}{
+		{map[string]string{"type": "number"}, "10"},
+		{map[string]string{"type": "number", "min": "18", "max": "60"}, "39"},
+		{map[string]string{"type": "number", "min": "1", "max": "10", "step": "3"}, "4"},
+		{map[string]string{"type": "number", "max": "5"}, "5"},
+		{map[string]string{"type": "number", "min": "0.5", "step": "0.25"}, "0.5"},
+		{map[string]string{"type": "range"}, "50"},
+	} {
+		value, ok := GenerateConstraintValue(item.attrs, "Universe")
+		if !ok || value != item.value {
+			t.Fatalf("attrs %v: expected %s, got %s", item.attrs, item.value, value)
+		}
+	}
+	if _, ok := GenerateConstraintValue(map[string]string{"type": "number", "min": "10", "max": "1"}, ""); ok {
+		t.Fatal("expected min > max to fail")
+	}
+}
+
+func TestGenerateConstraintValue_DateAndLength(t *testing.T) {
+	for _, item := range []struct {
+		attrs    map[string]string
+		fallback string
+		value    string
+	}{
+		{map[string]string{"type": "date"}, "", "2023-01-01"},
+		{map[string]string{"type": "date", "min": "2024-05-01"}, "", "2024-05-01"},
+		{map[string]string{"type": "date", "max": "2020-12-31"}, "", "2020-12-31"},
+		{map[string]string{"type": "email"}, "Universe", "universe@gmail.com"},
+		{map[string]string{"type": "url"}, "https://example.com/", "https://example.com/"},
+		{map[string]string{"maxlength": "4"}, "Universe", "Univ"},
+		{map[string]string{"minlength": "10"}, "Universe", "Universeaa"},
+	} {
+		value, ok := GenerateConstraintValue(item.attrs, item.fallback)
+		if !ok || value != item.value {
+			t.Fatalf("attrs %v: expected %s, got %s", item.attrs, item.value, value)
+		}
+	}
+}
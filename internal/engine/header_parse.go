@@ -0,0 +1,196 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/network"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 这里解析响应头中的地址和源,包括跳转、Link、CSP、CORS以及报告上报的地址,作为发现的输入
+
+// HeaderURL 响应头中解析出来的地址或域名
+type HeaderURL struct {
+	URL       string `json:"url"`       // 可以访问的地址,只有域名时为空
+	Domain    string `json:"domain"`    // 地址的域名,通配符域名去掉 *.
+	Header    string `json:"header"`    // 所在的响应头
+	Directive string `json:"directive"` // CSP的指令、Link的rel或Report-To的分组
+	Source    string `json:"source"`    // 来源标记
+	PageURL   string `json:"page_url"`  // 响应所属的请求地址
+}
+
+var (
+	// linkRegex Link响应头中的一项 <url>; rel="preload"
+	linkRegex = regexp.MustCompile(`<([^>]*)>([^<]*)`)
+	// linkRelRegex Link参数中的rel
+	linkRelRegex = regexp.MustCompile(`(?i)\brel\s*=\s*"?([^";,]+)"?`)
+	// refreshRegex Refresh响应头中的地址 5; url=/path
+	refreshRegex = regexp.MustCompile(`(?i)url\s*=\s*['"]?([^'"\s]+)`)
+	// reportingEndpointRegex Reporting-Endpoints 响应头中的一项 name="url"
+	reportingEndpointRegex = regexp.MustCompile(`([\w\-]+)\s*=\s*"([^"]+)"`)
+	// cspHostRegex CSP中的主机源 https://*.example.com:443/path
+	cspHostRegex = regexp.MustCompile(`^(?:([a-zA-Z][a-zA-Z0-9+.\-]*)://)?(\*\.)?([a-zA-Z0-9\-]+(?:\.[a-zA-Z0-9\-]+)*)(?::(?:\d+|\*))?(/[^\s]*)?$`)
+)
+
+// cspHeaders 内容安全策略响应头
+var cspHeaders = map[string]bool{
+	"content-security-policy":             true,
+	"content-security-policy-report-only": true,
+	"x-content-security-policy":           true,
+	"x-webkit-csp":                        true,
+}
+
+// ParseHeaderURLs 解析响应头中的地址和源,相对地址保持原样,由调用方按照响应地址解析
+func ParseHeaderURLs(headers map[string]string) []*HeaderURL {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []*HeaderURL
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		// 浏览器中同名的多个响应头以换行连接
+		for _, value := range strings.Split(headers[name], "\n") {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			switch {
+			case lower == "link":
+				result = append(result, parseLinkHeader(name, value)...)
+			case lower == "location" || lower == "content-location":
+				result = append(result, &HeaderURL{URL: value, Header: name, Source: enums2.FromHeader})
+			case lower == "refresh":
+				if match := refreshRegex.FindStringSubmatch(value); match != nil {
+					result = append(result, &HeaderURL{URL: match[1], Header: name, Source: enums2.FromHeader})
+				}
+			case cspHeaders[lower]:
+				result = append(result, ParseCSP(name, value)...)
+			case lower == "access-control-allow-origin":
+				if value != "*" && value != "null" {
+					result = append(result, &HeaderURL{URL: value, Header: name, Source: enums2.FromCORS})
+				}
+			case lower == "report-to":
+				result = append(result, parseReportTo(name, value)...)
+			case lower == "reporting-endpoints":
+				for _, match := range reportingEndpointRegex.FindAllStringSubmatch(value, -1) {
+					result = append(result, &HeaderURL{URL: match[2], Header: name, Directive: match[1], Source: enums2.FromReportTo})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// parseLinkHeader 解析Link响应头,一个响应头中可以有多个逗号分隔的地址
+func parseLinkHeader(name string, value string) []*HeaderURL {
+	var result []*HeaderURL
+	for _, match := range linkRegex.FindAllStringSubmatch(value, -1) {
+		link := &HeaderURL{URL: strings.TrimSpace(match[1]), Header: name, Source: enums2.FromHeader}
+		if rel := linkRelRegex.FindStringSubmatch(match[2]); rel != nil {
+			link.Directive = strings.ToLower(strings.TrimSpace(rel[1]))
+		}
+		if link.URL != "" {
+			result = append(result, link)
+		}
+	}
+	return result
+}
+
+// ParseCSP 解析内容安全策略中每个指令的源,report-uri的值为地址,其余指令中的主机源记录为域名
+func ParseCSP(name string, value string) []*HeaderURL {
+	var result []*HeaderURL
+	for _, directive := range strings.Split(value, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) < 2 {
+			continue
+		}
+		directiveName := strings.ToLower(fields[0])
+		for _, token := range fields[1:] {
+			if directiveName == "report-uri" {
+				result = append(result, &HeaderURL{URL: token, Header: name, Directive: directiveName, Source: enums2.FromCSP})
+				continue
+			}
+			// report-to的值为Report-To中的分组名
+			if directiveName == "report-to" || strings.HasPrefix(token, "'") || token == "*" || strings.HasSuffix(token, ":") {
+				continue
+			}
+			match := cspHostRegex.FindStringSubmatch(token)
+			if match == nil || !strings.Contains(match[3], ".") {
+				continue
+			}
+			item := &HeaderURL{Domain: strings.ToLower(match[3]), Header: name, Directive: directiveName, Source: enums2.FromCSP}
+			// 没有通配符的主机源可以直接访问,没有协议时由调用方使用页面的协议
+			if match[2] == "" && (match[1] == "" || match[1] == "http" || match[1] == "https") {
+				item.URL = token
+				if match[1] == "" {
+					item.URL = "//" + token
+				}
+			}
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// parseReportTo 解析Report-To响应头,值为一个或多个逗号连接的json对象
+func parseReportTo(name string, value string) []*HeaderURL {
+	var groups []struct {
+		Group     string `json:"group"`
+		Endpoints []struct {
+			URL string `json:"url"`
+		} `json:"endpoints"`
+	}
+	if err := json.Unmarshal([]byte("["+value+"]"), &groups); err != nil {
+		return nil
+	}
+	var result []*HeaderURL
+	for _, group := range groups {
+		for _, endpoint := range group.Endpoints {
+			if endpoint.URL != "" {
+				result = append(result, &HeaderURL{URL: endpoint.URL, Header: name, Directive: group.Group, Source: enums2.FromReportTo})
+			}
+		}
+	}
+	return result
+}
+
+// ParseRequestURLFromResponseHeader 解析请求的url从返回的响应头中
+func (tab *Tab) ParseRequestURLFromResponseHeader(v *network.EventResponseReceived) {
+	defer tab.WaitGroup.Done()
+	headers := make(map[string]string, len(v.Response.Headers))
+	for key, value := range v.Response.Headers {
+		headers[key] = fmt.Sprint(value)
+	}
+	base, err := urllib.GetURL(v.Response.URL, *tab.NavigateRequest.URL)
+	if err != nil {
+		base = tab.NavigateRequest.URL
+	}
+	var found []*HeaderURL
+	for _, item := range ParseHeaderURLs(headers) {
+		item.PageURL = base.String()
+		if item.URL != "" {
+			u, err := urllib.GetURL(item.URL, *base)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				item.URL = ""
+			} else {
+				item.URL = u.String()
+				item.Domain = u.Hostname()
+				tab.AddResultFormCustomUrl(enums2.GET, item.URL, item.Source)
+			}
+		}
+		if item.Domain != "" {
+			found = append(found, item)
+		}
+	}
+	if len(found) == 0 {
+		return
+	}
+	tab.Lock.Lock()
+	tab.HeaderURLList = append(tab.HeaderURLList, found...)
+	tab.Lock.Unlock()
+}
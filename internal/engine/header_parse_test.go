@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestParseHeaderURLs(t *testing.T) {
+	headers := map[string]string{
+		"Link":                        `</static/app.css>; rel="preload"; as=style, <https://cdn.example.com/font.woff2>; rel=preconnect`,
+		"Location":                    "/login?next=%2F",
+		"Refresh":                     "5; url='/timeout.html'",
+		"Access-Control-Allow-Origin": "https://admin.example.com",
+		"Content-Security-Policy":     "default-src 'self'; connect-src 'self' https://api.example.com wss://ws.example.com *.internal.example.com data:; script-src 'nonce-abc' cdn.example.com/js/ 'strict-dynamic'; report-uri /csp-report; report-to csp",
+		"Report-To":                   `{"group":"csp","max_age":10886400,"endpoints":[{"url":"https://report.example.com/csp"}]}, {"group":"nel","endpoints":[{"url":"https://report.example.com/nel"}]}`,
+		"Reporting-Endpoints":         `default="https://report.example.com/default"`,
+	}
+	var got []string
+	for _, item := range ParseHeaderURLs(headers) {
+		got = append(got, item.Source+" "+item.Directive+" "+item.URL+" "+item.Domain)
+	}
+	expected := []string{
+		"CORS  https://admin.example.com ",
+		"CSP connect-src https://api.example.com api.example.com",
+		"CSP connect-src  ws.example.com",
+		"CSP connect-src  internal.example.com",
+		"CSP script-src //cdn.example.com/js/ cdn.example.com",
+		"CSP report-uri /csp-report ",
+		"Header preload /static/app.css ",
+		"Header preconnect https://cdn.example.com/font.woff2 ",
+		"Header  /login?next=%2F ",
+		"Header  /timeout.html ",
+		"ReportTo csp https://report.example.com/csp ",
+		"ReportTo nel https://report.example.com/nel ",
+		"ReportTo default https://report.example.com/default ",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected urls %q", got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %q, got %q", expected[i], got[i])
+		}
+	}
+}
+
+func TestParseHeaderURLs_Ignored(t *testing.T) {
+	headers := map[string]string{
+		"Access-Control-Allow-Origin": "*",
+		"Content-Security-Policy":     "default-src 'none'; img-src * blob: data:; frame-ancestors 'self'",
+		"Report-To":                   "not json",
+	}
+	if result := ParseHeaderURLs(headers); len(result) != 0 {
+		t.Fatalf("expected nothing, got %+v", result)
+	}
+}
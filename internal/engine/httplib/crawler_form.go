@@ -0,0 +1,203 @@
+package httplib
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Form 从页面中解析出来的结构化表单
+type Form struct {
+	URL     string      `json:"url"`     // 表单所在的页面地址
+	Frame   string      `json:"frame"`   // 表单所在的frame地址,顶层页面为空
+	Action  string      `json:"action"`  // 表单提交地址
+	Method  string      `json:"method"`  // 表单提交方法
+	Enctype string      `json:"enctype"` // 表单编码类型
+	Fields  []FormField `json:"fields"`  // 表单字段
+}
+
+// FormField 表单中的一个字段
+type FormField struct {
+	Name        string            `json:"name"`        // 字段名
+	Type        string            `json:"type"`        // 字段类型,input的type或者select、textarea
+	Default     string            `json:"default"`     // 字段默认值
+	Value       string            `json:"value"`       // 填充后的当前值
+	Required    bool              `json:"required"`    // 是否必填
+	Checked     bool              `json:"checked"`     // checkbox、radio是否选中
+	Accept      string            `json:"accept"`      // 文件上传允许的类型
+	Options     []string          `json:"options"`     // select的可选值
+	File        string            `json:"file"`        // 文件字段上传的本地样例文件
+	Constraints map[string]string `json:"constraints"` // HTML5校验约束属性,pattern、min、max等
+}
+
+// ActionURL 返回表单的提交地址,没有action时提交到表单所在的页面
+func (form *Form) ActionURL() (*urllib.URL, error) {
+	page, err := urllib.GetURL(form.URL)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(form.Action) == "" {
+		return page, nil
+	}
+	return urllib.GetURL(form.Action, *page)
+}
+
+// RequestMethod 返回表单的提交方法,只有post会以POST提交
+func (form *Form) RequestMethod() string {
+	if strings.ToUpper(form.Method) == enums.POST {
+		return enums.POST
+	}
+	return enums.GET
+}
+
+// Values 返回表单会提交的字段值,未选中的checkbox不提交,没有选中项的radio组提交第一个
+func (form *Form) Values() [][2]string {
+	var values [][2]string
+	radioChecked := map[string]bool{}
+	for _, field := range form.Fields {
+		if field.Type == "radio" && field.Checked {
+			radioChecked[field.Name] = true
+		}
+	}
+	for _, field := range form.Fields {
+		value := field.Value
+		if value == "" {
+			value = field.Default
+		}
+		switch field.Type {
+		case "file":
+			continue
+		case "checkbox":
+			if !field.Checked {
+				continue
+			}
+		case "radio":
+			if radioChecked[field.Name] && !field.Checked {
+				continue
+			}
+			radioChecked[field.Name] = true
+		}
+		values = append(values, [2]string{field.Name, value})
+	}
+	return values
+}
+
+// ToRequest 根据表单合成一个爬虫请求,GET表单的字段拼接到query中,POST表单按照enctype编码请求体
+func (form *Form) ToRequest() (*RequestCrawler, error) {
+	actionURL, err := form.ActionURL()
+	if err != nil {
+		return nil, err
+	}
+	method := form.RequestMethod()
+	values := form.Values()
+	headers := map[string]interface{}{}
+	var postData string
+	if method == enums.GET {
+		// GET表单提交时会替换掉action中原有的query
+		query := url.Values{}
+		for _, value := range values {
+			query.Add(value[0], value[1])
+		}
+		actionURL.RawQuery = query.Encode()
+	} else {
+		switch {
+		case strings.HasPrefix(strings.ToLower(form.Enctype), enums.MULTIPART):
+			var buf bytes.Buffer
+			writer := multipart.NewWriter(&buf)
+			// 随机的boundary会让同一个表单每次生成不同的请求体和请求id,这里使用由表单内容计算的boundary
+			if err = writer.SetBoundary(form.multipartBoundary(values)); err != nil {
+				return nil, err
+			}
+			for _, value := range values {
+				if err = writer.WriteField(value[0], value[1]); err != nil {
+					return nil, err
+				}
+			}
+			for _, field := range form.Fields {
+				if field.Type != "file" {
+					continue
+				}
+				if err = writeFormFile(writer, field); err != nil {
+					return nil, err
+				}
+			}
+			if err = writer.Close(); err != nil {
+				return nil, err
+			}
+			postData = buf.String()
+			headers["Content-Type"] = writer.FormDataContentType()
+		case strings.HasPrefix(strings.ToLower(form.Enctype), "text/plain"):
+			var lines []string
+			for _, value := range values {
+				lines = append(lines, fmt.Sprintf("%s=%s", value[0], value[1]))
+			}
+			postData = strings.Join(lines, "\r\n")
+			headers["Content-Type"] = "text/plain"
+		default:
+			query := url.Values{}
+			for _, value := range values {
+				query.Add(value[0], value[1])
+			}
+			postData = query.Encode()
+			headers["Content-Type"] = enums.URLENCODED
+		}
+	}
+	if actionURL.Scheme != "http" && actionURL.Scheme != "https" {
+		return nil, errors.New("unsupported form action scheme: " + actionURL.Scheme)
+	}
+	return GetCrawlerRequest(method, actionURL, OptionsCrawler{Headers: headers, PostData: postData}), nil
+}
+
+// multipartBoundary 根据提交地址、字段值和上传的文件名计算固定的multipart boundary
+func (form *Form) multipartBoundary(values [][2]string) string {
+	hash := sha1.New()
+	hash.Write([]byte(form.URL + "\n" + form.Action + "\n"))
+	for _, value := range values {
+		hash.Write([]byte(value[0] + "=" + value[1] + "\n"))
+	}
+	for _, field := range form.Fields {
+		if field.Type == "file" {
+			hash.Write([]byte(field.Name + "=" + filepath.Base(field.File) + "\n"))
+		}
+	}
+	return "----CrawlergoFormBoundary" + hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// quoteEscaper 与 mime/multipart 相同的参数值转义,字段名来自页面,未转义的引号和换行会破坏请求体
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"", "\r", "%0D", "\n", "%0A")
+
+// writeFormFile 写入文件字段,没有可上传的文件时写入空文件
+func writeFormFile(writer *multipart.Writer, field FormField) error {
+	if field.File == "" {
+		_, err := writer.CreateFormFile(field.Name, "")
+		return err
+	}
+	content, err := os.ReadFile(field.File)
+	if err != nil {
+		return err
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(field.File))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(field.Name), quoteEscaper.Replace(filepath.Base(field.File))))
+	header.Set("Content-Type", mimeType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
@@ -0,0 +1,116 @@
+package httplib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestForm_ToRequest(t *testing.T) {
+	form := Form{
+		URL:    "http://testphp.vulnweb.com/search.php?old=1",
+		Action: "search.php?test=query",
+		Method: "get",
+		Fields: []FormField{
+			{Name: "searchFor", Type: "text", Value: "Universe"},
+			{Name: "remember", Type: "checkbox", Value: "on"},
+		},
+	}
+	req, err := form.ToRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "GET" || req.URL.String() != "http://testphp.vulnweb.com/search.php?searchFor=Universe" {
+		t.Fatalf("unexpected request: %s %s", req.Method, req.URL.String())
+	}
+}
+
+func TestForm_ToRequestMultipart(t *testing.T) {
+	form := Form{
+		URL:     "http://testphp.vulnweb.com/upload/",
+		Action:  "/upload.php",
+		Method:  "post",
+		Enctype: "multipart/form-data",
+		Fields: []FormField{
+			{Name: "title", Type: "text", Default: "image"},
+			{Name: "kind", Type: "radio", Value: "a"},
+			{Name: "kind", Type: "radio", Value: "b"},
+			{Name: "upfile", Type: "file"},
+		},
+	}
+	req, err := form.ToRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.URL.String() != "http://testphp.vulnweb.com/upload.php" {
+		t.Fatalf("unexpected request: %s %s", req.Method, req.URL.String())
+	}
+	postData := req.CrawlerPostData()
+	if postData["title"] != "image" || postData["kind"] != "a" {
+		t.Fatalf("unexpected post data: %v", postData)
+	}
+	if _, ok := postData["upfile"]; !ok {
+		t.Fatalf("file field missing: %v", postData)
+	}
+}
+
+func TestForm_ToRequestMultipartStable(t *testing.T) {
+	form := Form{
+		URL:     "http://testphp.vulnweb.com/upload/",
+		Action:  "/upload.php",
+		Method:  "post",
+		Enctype: "multipart/form-data",
+		Fields: []FormField{
+			{Name: "title", Type: "text", Default: "image"},
+			{Name: "upfile", Type: "file"},
+		},
+	}
+	first, err := form.ToRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := form.ToRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.UniqueId() != second.UniqueId() || first.PostData != second.PostData {
+		t.Fatalf("same form produced different requests: %s %s", first.UniqueId(), second.UniqueId())
+	}
+	form.Fields[0].Default = "other"
+	third, err := form.ToRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.UniqueId() == first.UniqueId() {
+		t.Fatal("different form values should produce different requests")
+	}
+}
+
+func TestForm_ToRequestMultipartQuotedName(t *testing.T) {
+	file := filepath.Join(t.TempDir(), `sample"1.png`)
+	if err := os.WriteFile(file, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	form := Form{
+		URL:     "http://testphp.vulnweb.com/upload/",
+		Action:  "/upload.php",
+		Method:  "post",
+		Enctype: "multipart/form-data",
+		Fields: []FormField{
+			{Name: `title"x`, Type: "text", Default: "image"},
+			{Name: "up\"file\r\nX-Injected: 1", Type: "file", File: file},
+		},
+	}
+	req, err := form.ToRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(req.PostData, "\r\nX-Injected: 1") {
+		t.Fatal("field name injected a part header")
+	}
+	postData := req.CrawlerPostData()
+	if postData[`title"x`] != "image" || postData[`up"file%0D%0AX-Injected: 1`] != `sample"1.png` {
+		t.Fatalf("unexpected post data: %v", postData)
+	}
+}
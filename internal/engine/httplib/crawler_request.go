@@ -6,6 +6,9 @@ import (
 	"github.com/sairson/crawlergo/internal/engine/enums"
 	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
 	"github.com/sairson/crawlergo/pkg/utils"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/url"
 	"strings"
 )
@@ -25,6 +28,16 @@ type RequestCrawler struct {
 	Source      string                 // 请求源
 	Redirection bool                   // 重定向标志
 	Proxy       string                 // 代理
+	Device      string                 // 发现该请求时使用的设备配置
+	ActionPath  []Action               // 探索模式下触发该请求需要依次重放的动作
+	Response    *ResponseInfo          // 请求的响应信息,未发出或被阻止的请求为空
+	NoNavigate  bool                   // 只作为结果记录,不会被导航爬取,例如脚本中解析出来的非只读方法请求
+}
+
+// Action 探索模式下在页面上执行的一次动作
+type Action struct {
+	Selector string `json:"selector"` // 动作元素的css选择器
+	Label    string `json:"label"`    // 动作元素的文本描述
 }
 
 type Filter struct {
@@ -37,6 +50,8 @@ type Filter struct {
 	QueryKeysId       string
 	QueryMapId        string
 	MarkedPostDataMap map[string]interface{}
+	Reason            string   // 被过滤的原因,未被过滤时为空
+	MarkRules         []string // 超过阈值后修正标记的规则
 }
 
 func GetCrawlerRequest(method string, URL *urllib.URL, options ...OptionsCrawler) *RequestCrawler {
@@ -99,6 +114,14 @@ func (req *RequestCrawler) CrawlerPostData() map[string]interface{} {
 		} else {
 			return result
 		}
+	} else if strings.HasPrefix(contentType, enums.MULTIPART) {
+		result, err := parseMultipartPostData(contentType, req.PostData)
+		if err != nil {
+			return map[string]interface{}{
+				"key": req.PostData,
+			}
+		}
+		return result
 	} else if strings.HasPrefix(contentType, enums.URLENCODED) {
 		var result = map[string]interface{}{}
 		r, err := url.ParseQuery(req.PostData)
@@ -123,6 +146,32 @@ func (req *RequestCrawler) CrawlerPostData() map[string]interface{} {
 	}
 }
 
+// parseMultipartPostData 解析multipart请求体,文件字段的值为文件名
+func parseMultipartPostData(contentType string, postData string) (map[string]interface{}, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	reader := multipart.NewReader(strings.NewReader(postData), params["boundary"])
+	var result = map[string]interface{}{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FileName() != "" {
+			result[part.FormName()] = part.FileName()
+		} else {
+			value, _ := io.ReadAll(part)
+			result[part.FormName()] = string(value)
+		}
+	}
+	return result, nil
+}
+
 // UniqueId 计算请求头md5hash
 func (req *RequestCrawler) UniqueId() string {
 	if req.Redirection {
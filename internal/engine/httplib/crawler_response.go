@@ -3,13 +3,18 @@ package httplib
 import (
 	"github.com/axgle/mahonia"
 	"github.com/saintfish/chardet"
+	"mime"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type ResponseCrawler struct {
 	http.Response
-	Body     []byte // 原始文本对于response
-	Encoding string // 文本编码类型
+	Body     []byte        // 原始文本对于response
+	Encoding string        // 文本编码类型
+	RemoteIP string        // 服务端的IP地址
+	Elapsed  time.Duration // 从发出请求到读取完响应的时间
 }
 
 // ToText 返回受编码的文本
@@ -28,3 +33,32 @@ func DetectEncoding(content []byte) (string, error) {
 	result, err := detector.DetectBest(content)
 	return result.Charset, err
 }
+
+// ResponseInfo 请求对应的响应信息
+type ResponseInfo struct {
+	StatusCode int               `json:"status_code"` // 响应状态码
+	Headers    map[string]string `json:"headers"`     // 响应头
+	MimeType   string            `json:"mime_type"`   // 响应的MIME类型
+	BodySize   int64             `json:"body_size"`   // 响应体的字节数
+	RemoteIP   string            `json:"remote_ip"`   // 服务端的IP地址
+	Timing     float64           `json:"timing"`      // 从发出请求到接收完响应的毫秒数
+	BodyHash   string            `json:"body_hash"`   // 响应体在存储中的SHA-256,未存储时为空
+}
+
+// NewResponseInfo 从请求的响应中生成响应信息
+func NewResponseInfo(resp *ResponseCrawler) *ResponseInfo {
+	info := &ResponseInfo{
+		StatusCode: resp.StatusCode,
+		Headers:    make(map[string]string, len(resp.Header)),
+		BodySize:   int64(len(resp.Body)),
+		RemoteIP:   resp.RemoteIP,
+		Timing:     float64(resp.Elapsed) / float64(time.Millisecond),
+	}
+	for key, values := range resp.Header {
+		info.Headers[key] = strings.Join(values, ", ")
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		info.MimeType = mediaType
+	}
+	return info
+}
@@ -0,0 +1,29 @@
+package httplib
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewResponseInfo(t *testing.T) {
+	resp := &ResponseCrawler{
+		Response: http.Response{
+			StatusCode: 404,
+			Header: http.Header{
+				"Content-Type": {"text/html; charset=utf-8"},
+				"Set-Cookie":   {"a=1", "b=2"},
+			},
+		},
+		Body:     []byte("not found"),
+		RemoteIP: "127.0.0.1",
+		Elapsed:  1500 * time.Microsecond,
+	}
+	info := NewResponseInfo(resp)
+	if info.StatusCode != 404 || info.MimeType != "text/html" || info.BodySize != 9 || info.RemoteIP != "127.0.0.1" || info.Timing != 1.5 {
+		t.Fatalf("unexpected response info %+v", info)
+	}
+	if info.Headers["Set-Cookie"] != "a=1, b=2" {
+		t.Fatalf("unexpected headers %v", info.Headers)
+	}
+}
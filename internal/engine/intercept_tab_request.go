@@ -15,9 +15,13 @@ import (
 	"github.com/sairson/crawlergo/internal/engine/httplib"
 	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
 	"github.com/sairson/crawlergo/internal/engine/requests"
+	"github.com/sairson/crawlergo/internal/graphql"
+	"github.com/sairson/crawlergo/internal/jsast"
 	"github.com/sairson/crawlergo/internal/option"
 	"github.com/sairson/crawlergo/pkg/utils"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/textproto"
 	"regexp"
 	"strconv"
@@ -39,7 +43,9 @@ func (tab *Tab) AddTabRequestToResultList(req *httplib.RequestCrawler) {
 	for key, value := range tab.ExtraHeaders {
 		req.Headers[key] = value
 	}
+	req.Device = tab.config.Device.Name
 	tab.Lock.Lock()
+	req.ActionPath = tab.ActionPath
 	tab.ResultList = append(tab.ResultList, req)
 	if tab.ResultCallback != nil {
 		_ = tab.ResultCallback(req) // 执行结果回调
@@ -49,14 +55,29 @@ func (tab *Tab) AddTabRequestToResultList(req *httplib.RequestCrawler) {
 
 // AddResultFormCustomUrl 添加一个成果从自定义url
 func (tab *Tab) AddResultFormCustomUrl(method string, _url string, source string) {
+	tab.AddResultFormCustomRequest(method, _url, "", "", source)
+}
+
+// AddResultFormCustomRequest 添加一个带有请求体的自定义请求,contentType为空时不设置请求体类型
+func (tab *Tab) AddResultFormCustomRequest(method string, _url string, postData string, contentType string, source string) {
+	if req := tab.NewCustomRequest(method, _url, postData, contentType, source); req != nil {
+		tab.AddCustomRequestToResultList(req)
+	}
+}
+
+// NewCustomRequest 按照当前页面的请求头生成一个自定义请求,地址无法解析时返回nil
+func (tab *Tab) NewCustomRequest(method string, _url string, postData string, contentType string, source string) *httplib.RequestCrawler {
 	navUrl := tab.NavigateRequest.URL
 	url, err := urllib.GetURL(_url, *navUrl)
 	if err != nil {
-		return
+		return nil
 	}
 	crawlerOption := httplib.OptionsCrawler{
 		Headers:  map[string]interface{}{},
-		PostData: "",
+		PostData: postData,
+	}
+	if contentType != "" {
+		crawlerOption.Headers["Content-Type"] = contentType
 	}
 	referer := navUrl.String()
 	// 处理Host绑定
@@ -78,7 +99,14 @@ func (tab *Tab) AddResultFormCustomUrl(method string, _url string, source string
 	}
 	req := httplib.GetCrawlerRequest(method, url, crawlerOption)
 	req.Source = source
+	req.Device = tab.config.Device.Name
+	return req
+}
+
+// AddCustomRequestToResultList 将自定义请求添加到结果列表
+func (tab *Tab) AddCustomRequestToResultList(req *httplib.RequestCrawler) {
 	tab.Lock.Lock()
+	req.ActionPath = tab.ActionPath
 	// 直接将结果添加到结果列表
 	tab.ResultList = append(tab.ResultList, req)
 	if tab.ResultCallback != nil {
@@ -99,10 +127,16 @@ func (tab *Tab) InterceptTabRequest(v *fetch.EventRequestPaused) {
 		_ = fetch.ContinueRequest(v.RequestID).Do(ctx)
 		return
 	}
-	// 我们记录我们拦截到的请求头和post data数据
+	// 我们记录我们拦截到的请求头和post data数据,请求体过大(例如文件上传)时拦截事件中不携带post data,需要单独获取
+	postData := v.Request.PostData
+	if postData == "" && v.Request.HasPostData && v.NetworkID != "" {
+		tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+		postData, _ = network.GetRequestPostData(v.NetworkID).Do(tCtx)
+		cancel()
+	}
 	_option := httplib.OptionsCrawler{
 		Headers:  v.Request.Headers,
-		PostData: v.Request.PostData,
+		PostData: postData,
 	}
 	// 通过这个option,我们生成一个爬虫请求
 	crawlerRequest := httplib.GetCrawlerRequest(v.Request.Method, url, _option)
@@ -114,6 +148,13 @@ func (tab *Tab) InterceptTabRequest(v *fetch.EventRequestPaused) {
 		tab.AddTabRequestToResultList(crawlerRequest)
 		return
 	}
+	// 只读模式下阻止可能修改数据的请求,但仍然记录到结果中
+	if tab.config.ReadOnly && !IsSafeMethod(crawlerRequest.Method) {
+		_ = fetch.FailRequest(v.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+		crawlerRequest.Source = enums2.FromXHR
+		tab.AddTabRequestToResultList(crawlerRequest)
+		return
+	}
 	// 这一步我们要不要做host绑定？
 	tab.HandleHostBinding(crawlerRequest)
 
@@ -128,17 +169,55 @@ func (tab *Tab) InterceptTabRequest(v *fetch.EventRequestPaused) {
 	// 处理导航请求
 	if tab.IsNavigatorRequest(v.NetworkID.String()) {
 		tab.NavNetworkID = v.NetworkID.String()
+		tab.BindNetworkRequest(v.NetworkID.String(), crawlerRequest)
 		tab.HandlerCrawlerNavigationRequest(crawlerRequest, v) // 处理导航请求
 		// 添加结果
 		crawlerRequest.Source = enums2.FromNavigation
 		tab.AddTabRequestToResultList(crawlerRequest)
 		return
 	}
+	// 状态探索时阻止顶层页面跳转到其他地址,重新导航到当前页面用于重放动作路径
+	if tab.config.SPAExplore && v.ResourceType == network.ResourceTypeDocument && tab.IsTopFrame(v.FrameID.String()) {
+		if crawlerRequest.URL.NavigationUrl() == tab.NavigateRequest.URL.NavigationUrl() {
+			_ = fetch.ContinueRequest(v.RequestID).Do(ctx)
+			return
+		}
+		_ = fetch.FulfillRequest(v.RequestID, 204).Do(ctx)
+		crawlerRequest.Source = enums2.FromNavigation
+		tab.AddTabRequestToResultList(crawlerRequest)
+		return
+	}
 	crawlerRequest.Source = enums2.FromXHR
+	if IsUploadRequest(crawlerRequest) {
+		crawlerRequest.Source = enums2.FromUpload
+	}
+	tab.BindNetworkRequest(v.NetworkID.String(), crawlerRequest)
 	tab.AddTabRequestToResultList(crawlerRequest)
 	_ = fetch.ContinueRequest(v.RequestID).Do(ctx)
 }
 
+// IsUploadRequest 判断是否为携带文件的multipart上传请求,任意一个部分带有文件名即为上传请求
+func IsUploadRequest(req *httplib.RequestCrawler) bool {
+	contentType, err := req.ContentType()
+	if err != nil || !strings.HasPrefix(contentType, enums2.MULTIPART) {
+		return false
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	reader := multipart.NewReader(strings.NewReader(req.PostData), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return false
+		}
+		if part.FileName() != "" {
+			return true
+		}
+	}
+}
+
 // HandleHostBinding 将请求与我们的Navigate request做绑定
 func (tab *Tab) HandleHostBinding(req *httplib.RequestCrawler) {
 	url := req.URL
@@ -267,39 +346,74 @@ func (tab *Tab) ParseRequestURLFormResponseText(v *network.EventResponseReceived
 	if err != nil {
 		return
 	}
+	tab.StoreResponseBody(v.RequestID.String(), v.Response.MimeType, resp)
 	respBody := string(resp)
-	// 执行url匹配正则
+	// 解析脚本和页面中的前端路由
+	if tab.config.ClientRoute && !strings.Contains(strings.ToLower(v.Response.MimeType), "text/css") {
+		tab.AddClientRoutes(ExtractClientRoutes(respBody))
+	}
+	// 解析脚本中的GraphQL操作
+	if !strings.Contains(strings.ToLower(v.Response.MimeType), "text/css") {
+		tab.AddGraphQLOperations(respBody)
+	}
+	// 获取并还原脚本和样式引用的source map
+	if tab.config.SourceMap && (strings.Contains(strings.ToLower(v.Response.MimeType), "javascript") || strings.Contains(strings.ToLower(v.Response.MimeType), "text/css")) {
+		tab.ProcessSourceMap(v.Response.URL, respBody, v.Response.Headers)
+	}
+	// 解析脚本的语法树,提取拼接出来的地址和请求调用
+	if strings.Contains(strings.ToLower(v.Response.MimeType), "javascript") {
+		tab.AddJSEndpoints(respBody, enums2.FromJSFile)
+	}
+	tab.ExtractSuspectURLs(respBody, enums2.FromJSFile)
+	tab.MatchCustomDefinedRegex(respBody)
+}
+
+// ExtractSuspectURLs 执行url匹配正则,将文本中疑似链接的字符串添加到结果中
+func (tab *Tab) ExtractSuspectURLs(text string, source string) {
 	urlRegex := regexp.MustCompile(enums2.SuspectURLRegex)
-	urlList := urlRegex.FindAllString(respBody, -1)
+	urlList := urlRegex.FindAllString(text, -1)
 	// 遍历全部url列表
 	for _, url := range urlList {
 		url = url[1 : len(url)-1]
 		urlLower := strings.ToLower(url)
-		if strings.HasPrefix(urlLower, "image/x-icon") || strings.HasPrefix(urlLower, "text/css") || strings.HasPrefix(urlLower, "text/javascript") {
+		if strings.HasPrefix(urlLower, "image/x-icon") || strings.HasPrefix(urlLower, "text/css") || strings.HasPrefix(urlLower, "text/javascript") || jsast.IsMimeType(urlLower) {
 			continue
 		}
-		tab.AddResultFormCustomUrl(enums2.GET, url, enums2.FromJSFile)
+		tab.AddResultFormCustomUrl(enums2.GET, url, source)
 	}
-	// 执行用户自定义正则
+}
+
+// MatchCustomDefinedRegex 执行用户自定义正则
+func (tab *Tab) MatchCustomDefinedRegex(text string) {
 	for _, custom := range tab.config.CustomDefinedRegex {
 		customRegex := regexp.MustCompile(custom)
-		customList := customRegex.FindAllString(respBody, -1)
+		customList := customRegex.FindAllString(text, -1)
+		tab.Lock.Lock()
 		tab.CustomDefinedRegexResultList = append(tab.CustomDefinedRegexResultList, struct {
 			Regexp string
 			Result []string
 		}{Regexp: custom, Result: customList})
+		tab.Lock.Unlock()
 	}
 }
 
-// ParseRequestURLFromResponseHeader 解析请求的url从返回的响应头中
-func (tab *Tab) ParseRequestURLFromResponseHeader(v *network.EventResponseReceived) {
-	defer tab.WaitGroup.Done()
-	// 这个响应头也许也会包含诸多的链接
-	for key, _ := range v.Response.Headers {
-		if key == "Link" || key == "Content-Location" || key == "Location" || key == "Refresh" {
-			tab.AddResultFormCustomUrl(enums2.GET, v.Response.URL, enums2.FromHeader)
+// AddGraphQLOperations 记录脚本中的GraphQL操作,脚本中只有一个GraphQL地址时作为这些操作的地址
+func (tab *Tab) AddGraphQLOperations(js string) {
+	operations := graphql.ExtractOperations(js)
+	if len(operations) == 0 {
+		return
+	}
+	if endpoints := graphql.ExtractEndpoints(js); len(endpoints) == 1 {
+		if endpoint, err := urllib.GetURL(endpoints[0], *tab.NavigateRequest.URL); err == nil {
+			for _, op := range operations {
+				op.Endpoint = endpoint.NoQueryUrl()
+				op.Method = enums2.POST
+			}
 		}
 	}
+	tab.Lock.Lock()
+	tab.GraphQLOperationList = append(tab.GraphQLOperationList, operations...)
+	tab.Lock.Unlock()
 }
 
 // GetContentCharset 从请求头中获取字符编码
@@ -378,6 +492,11 @@ func (tab *Tab) HandleBindingCalled(event *runtime.EventBindingCalled) {
 	_ = json.Unmarshal(payload, &bcPayload)
 	if bcPayload.Name == "addLink" && len(bcPayload.Args) > 1 {
 		tab.AddResultFormCustomUrl(enums2.GET, bcPayload.Args[0], bcPayload.Args[1])
+		// worker脚本需要单独获取并解析
+		if bcPayload.Args[1] == enums2.FromServiceWorker || bcPayload.Args[1] == enums2.FromWorker {
+			tab.WaitGroup.Add(1)
+			go tab.ProcessWorkerScript(bcPayload.Args[0], bcPayload.Args[1], 0)
+		}
 	}
 	if bcPayload.Name == "Test" {
 		fmt.Println(bcPayload.Args)
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"encoding/json"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/jsast"
+	"net/url"
+	"strings"
+)
+
+// AddJSEndpoints 解析脚本的语法树,将其中的请求调用和拼接出来的地址添加到结果中,
+// 对象字面量的属性名作为参数名记录,解析失败时返回false
+func (tab *Tab) AddJSEndpoints(js string, source string) bool {
+	endpoints, keys, err := jsast.Analyze(js)
+	if err != nil {
+		return false
+	}
+	tab.Lock.Lock()
+	tab.ScriptParamList = append(tab.ScriptParamList, keys...)
+	tab.Lock.Unlock()
+	f := &FillForm{Tab: tab}
+	for _, endpoint := range endpoints {
+		postData, contentType := EndpointBody(endpoint, f.GetMatchInputText)
+		var req *httplib.RequestCrawler
+		if endpoint.Method == enums2.GET || endpoint.Method == enums2.HEAD {
+			// GET请求的参数拼接到地址中
+			if postData != "" {
+				req = tab.NewCustomRequest(endpoint.Method, appendQuery(endpoint.URL, postData), "", "", source)
+			} else {
+				req = tab.NewCustomRequest(endpoint.Method, endpoint.URL, "", "", source)
+			}
+		} else {
+			req = tab.NewCustomRequest(endpoint.Method, endpoint.URL, postData, contentType, source)
+		}
+		if req == nil {
+			continue
+		}
+		// 脚本中的请求没有经过页面的交互,非只读方法和匹配危险关键字的地址只记录不导航,避免重放删除、修改之类的操作
+		req.NoNavigate = !IsSafeMethod(req.Method)
+		if field, keyword, ok := MatchDangerKeyword(ElementSemantics{Href: req.URL.String()}, tab.config.DangerKeywords); ok {
+			req.NoNavigate = true
+			tab.AddSkippedElement(&SkippedElement{
+				URL:     tab.NavigateRequest.URL.String(),
+				Tag:     "script",
+				Text:    req.Method + " " + req.URL.String(),
+				Field:   field,
+				Keyword: keyword,
+			})
+		}
+		tab.AddCustomRequestToResultList(req)
+	}
+	return true
+}
+
+// EndpointBody 按照请求体的编码方式生成请求体,参数值按照参数名匹配表单填充的值
+func EndpointBody(endpoint *jsast.Endpoint, value func(name string) string) (string, string) {
+	if len(endpoint.BodyKeys) == 0 {
+		return "", ""
+	}
+	values := make(map[string]string, len(endpoint.BodyKeys))
+	for _, key := range endpoint.BodyKeys {
+		if values[key] = value(key); values[key] == "" {
+			values[key] = "1"
+		}
+	}
+	isQuery := endpoint.Method == enums2.GET || endpoint.Method == enums2.HEAD
+	if endpoint.BodyType == jsast.BodyJSON && !isQuery {
+		data, _ := json.Marshal(values)
+		return string(data), enums2.JSON
+	}
+	form := url.Values{}
+	for key, v := range values {
+		form.Set(key, v)
+	}
+	return form.Encode(), enums2.URLENCODED
+}
+
+// appendQuery 向地址中添加查询参数
+func appendQuery(rawURL string, query string) string {
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + query
+	}
+	return rawURL + "?" + query
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"github.com/sairson/crawlergo/internal/jsast"
+	"reflect"
+	"testing"
+)
+
+func TestEndpointBody(t *testing.T) {
+	value := func(name string) string {
+		if name == "email" {
+			return "crawlergo@gmail.com"
+		}
+		return ""
+	}
+	body, contentType := EndpointBody(&jsast.Endpoint{Method: "POST", BodyKeys: []string{"email", "id"}, BodyType: jsast.BodyJSON}, value)
+	if body != `{"email":"crawlergo@gmail.com","id":"1"}` || contentType != "application/json" {
+		t.Fatalf("unexpected json body %s %s", body, contentType)
+	}
+	// GET请求的参数总是以查询字符串的形式生成
+	body, contentType = EndpointBody(&jsast.Endpoint{Method: "GET", BodyKeys: []string{"q"}, BodyType: jsast.BodyJSON}, value)
+	if body != "q=1" || contentType != "application/x-www-form-urlencoded" {
+		t.Fatalf("unexpected query %s %s", body, contentType)
+	}
+	if body, _ = EndpointBody(&jsast.Endpoint{Method: "POST"}, value); body != "" {
+		t.Fatalf("expected empty body, got %s", body)
+	}
+	if appendQuery("/a?x=1", "q=1") != "/a?x=1&q=1" || appendQuery("/a", "q=1") != "/a?q=1" {
+		t.Fatal("unexpected query append")
+	}
+}
+
+func TestAddJSEndpoints_NoNavigate(t *testing.T) {
+	navURL, _ := urllib.GetURL("https://example.com/app/")
+	tab := &Tab{
+		NavigateRequest: *httplib.GetCrawlerRequest("GET", navURL),
+		ExtraHeaders:    map[string]interface{}{},
+		config:          TabConfig{DangerKeywords: []string{"logout"}},
+	}
+	js := `fetch("/api/users");fetch("/api/users/1",{method:"DELETE"});axios.post("/api/orders",{id:1});fetch("/account/logout")`
+	if !tab.AddJSEndpoints(js, "js") {
+		t.Fatal("expected script to be parsed")
+	}
+	noNavigate := map[string]bool{}
+	for _, req := range tab.ResultList {
+		noNavigate[req.Method+" "+req.URL.Path] = req.NoNavigate
+	}
+	expected := map[string]bool{"GET /api/users": false, "DELETE /api/users/1": true, "POST /api/orders": true, "GET /account/logout": true}
+	if !reflect.DeepEqual(noNavigate, expected) {
+		t.Fatalf("expected %v, got %v", expected, noNavigate)
+	}
+	if len(tab.SkippedElementList) != 1 || tab.SkippedElementList[0].Keyword != "logout" {
+		t.Fatalf("expected dangerous endpoint to be recorded as skipped, got %v", tab.SkippedElementList)
+	}
+}
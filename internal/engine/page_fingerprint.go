@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"context"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/sairson/crawlergo/internal/filter"
+	"time"
+)
+
+// renderedOuterHTML 获取当前tab页渲染后的outer HTML
+func (tab *Tab) renderedOuterHTML(ctx context.Context) (string, error) {
+	doc, err := dom.GetDocument().Do(ctx)
+	if err != nil {
+		return "", err
+	}
+	return dom.GetOuterHTML().WithNodeID(doc.NodeID).Do(ctx)
+}
+
+// CollectPageFingerprint 计算渲染后页面的文本和DOM结构指纹,用于相似页面检测
+func (tab *Tab) CollectPageFingerprint() {
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	html, err := tab.renderedOuterHTML(tCtx)
+	if err != nil {
+		return
+	}
+	fingerprint := filter.FingerprintHTML(html)
+	tab.PageFingerprint = &fingerprint
+}
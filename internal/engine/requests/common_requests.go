@@ -5,11 +5,16 @@ import (
 	"crypto/tls"
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/sairson/crawlergo/internal/engine/enums"
 	"github.com/sairson/crawlergo/internal/engine/httplib"
 	"github.com/sairson/crawlergo/pkg/urllib"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -106,8 +111,7 @@ func (session *Session) doRequest(verb string, url string, headers map[string]st
 	}
 	// 设置默认的headers头
 	defaultHeaders := map[string]string{
-		"User-Agent": "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko)" +
-			" Chrome/76.0.3809.132 Safari/537.36 C845D9D38B3A68F4F74057DB542AD252 tx/2.0",
+		"User-Agent": enums.DefaultUA,
 		"Range":      fmt.Sprintf("bytes=0-%d", 10240), // 默认获取的最大相应内容，100一般适用绝大部分场景
 		"Connection": "close",
 	}
@@ -128,6 +132,18 @@ func (session *Session) doRequest(verb string, url string, headers map[string]st
 	// 覆盖Connection头
 	req.Header.Set("Connection", "close")
 
+	// 记录服务端的IP地址
+	var remoteIP string
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				remoteIP = host
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	start := time.Now()
+
 	// 请求
 	var resp *http.Response
 	for i := 0; i <= 0; i++ {
@@ -147,16 +163,40 @@ func (session *Session) doRequest(verb string, url string, headers map[string]st
 		resp.Status = "200 OK"
 	}
 
-	if resp.ContentLength > 0 {
-		b, err := ioutil.ReadAll(resp.Body)
+	// 分块传输的响应没有Content-Length,按照Range的上限读取响应体
+	if resp.ContentLength > 0 || resp.ContentLength == -1 {
+		var reader io.Reader = resp.Body
+		if resp.ContentLength == -1 {
+			reader = io.LimitReader(resp.Body, bodyReadLimit(req.Header.Get("Range")))
+		}
+		b, err := ioutil.ReadAll(reader)
+		_ = resp.Body.Close()
 		if err != nil {
 			return nil, err
 		}
-		_ = resp.Body.Close()
 		return &httplib.ResponseCrawler{
 			Response: *resp,
 			Body:     b,
+			RemoteIP: remoteIP,
+			Elapsed:  time.Since(start),
 		}, nil
 	}
 	return nil, fmt.Errorf("content-Length <= 0")
 }
+
+// bodyReadLimit 根据请求的Range头计算最多读取的响应体字节数,Range没有上限时使用默认的最大字节数
+func bodyReadLimit(rangeHeader string) int64 {
+	start, end, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(rangeHeader), "bytes="), "-")
+	if !ok {
+		return enums.ChunkedBodyMaxSize
+	}
+	first, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return enums.ChunkedBodyMaxSize
+	}
+	last, err := strconv.ParseInt(end, 10, 64)
+	if err != nil || last < first {
+		return enums.ChunkedBodyMaxSize
+	}
+	return last - first + 1
+}
@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/enums"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequest_Chunked(t *testing.T) {
+	body := strings.Repeat("a", 20000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 刷新后响应使用分块传输,没有Content-Length
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	resp, err := Get(server.URL, map[string]string{"Range": "bytes=0-"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ContentLength != -1 || string(resp.Body) != body {
+		t.Fatalf("expected full chunked body, got %d bytes", len(resp.Body))
+	}
+	// 默认的Range限制读取的字节数
+	resp, err = Get(server.URL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Body) != 10241 {
+		t.Fatalf("expected body limited by range, got %d bytes", len(resp.Body))
+	}
+}
+
+func TestBodyReadLimit(t *testing.T) {
+	for rangeHeader, expected := range map[string]int64{
+		"bytes=0-10240": 10241,
+		"bytes=100-199": 100,
+		"bytes=0-":      enums.ChunkedBodyMaxSize,
+		"":              enums.ChunkedBodyMaxSize,
+		"bytes=9-1":     enums.ChunkedBodyMaxSize,
+	} {
+		if limit := bodyReadLimit(rangeHeader); limit != expected {
+			t.Fatalf("range %q: expected %d, got %d", rangeHeader, expected, limit)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"strings"
+	"time"
+)
+
+// 这里处理浏览器中请求的响应信息,通过network的RequestID将响应事件关联到拦截时生成的请求上
+
+// BindNetworkRequest 关联拦截到的请求和network的RequestID,响应先于请求到达时直接附加响应信息
+func (tab *Tab) BindNetworkRequest(networkID string, req *httplib.RequestCrawler) {
+	if networkID == "" {
+		return
+	}
+	tab.ResponseLock.Lock()
+	defer tab.ResponseLock.Unlock()
+	if info, ok := tab.ResponseInfos[networkID]; ok {
+		req.Response = info
+	}
+	tab.NetworkRequests[networkID] = req
+}
+
+// HandleResponseReceived 记录响应状态码、响应头、MIME类型和服务端IP
+func (tab *Tab) HandleResponseReceived(v *network.EventResponseReceived) {
+	id := v.RequestID.String()
+	info := &httplib.ResponseInfo{
+		StatusCode: int(v.Response.Status),
+		Headers:    make(map[string]string, len(v.Response.Headers)),
+		MimeType:   v.Response.MimeType,
+		RemoteIP:   v.Response.RemoteIPAddress,
+	}
+	for key, value := range v.Response.Headers {
+		info.Headers[key] = fmt.Sprint(value)
+	}
+	tab.ResponseLock.Lock()
+	defer tab.ResponseLock.Unlock()
+	// 响应体可能在响应事件之前已经开始接收
+	if old, ok := tab.ResponseInfos[id]; ok {
+		info.BodySize = old.BodySize
+	}
+	if v.Response.Timing != nil {
+		info.Timing = v.Response.Timing.ReceiveHeadersEnd
+		tab.ResponseStartTimes[id] = v.Response.Timing.RequestTime
+	}
+	tab.ResponseInfos[id] = info
+	if req, ok := tab.NetworkRequests[id]; ok {
+		req.Response = info
+	}
+}
+
+// HandleDataReceived 累加接收到的响应体字节数
+func (tab *Tab) HandleDataReceived(v *network.EventDataReceived) {
+	id := v.RequestID.String()
+	tab.ResponseLock.Lock()
+	defer tab.ResponseLock.Unlock()
+	info, ok := tab.ResponseInfos[id]
+	if !ok {
+		info = &httplib.ResponseInfo{}
+		tab.ResponseInfos[id] = info
+	}
+	info.BodySize += v.DataLength
+}
+
+// HandleLoadingFinished 响应接收完成后计算请求的总耗时,并存储不需要解析的响应体
+func (tab *Tab) HandleLoadingFinished(v *network.EventLoadingFinished) {
+	id := v.RequestID.String()
+	tab.ResponseLock.Lock()
+	defer tab.ResponseLock.Unlock()
+	info, ok := tab.ResponseInfos[id]
+	if !ok {
+		return
+	}
+	// 需要解析的响应在解析时已经获取并存储了响应体
+	if tab.config.BodyStore != nil && !IsParsedResponseMime(info.MimeType) && tab.config.BodyStore.Allowed(info.MimeType, int(info.BodySize)) {
+		tab.WaitGroup.Add(1)
+		go tab.FetchAndStoreResponseBody(v.RequestID, info.MimeType)
+	}
+	start, started := tab.ResponseStartTimes[id]
+	if !started || v.Timestamp == nil {
+		return
+	}
+	finished := float64(v.Timestamp.Time().Sub(*cdp.MonotonicTimeEpoch)) / float64(time.Second)
+	if finished > start {
+		info.Timing = (finished - start) * 1000
+	}
+	delete(tab.ResponseStartTimes, id)
+}
+
+// FetchAndStoreResponseBody 获取响应体并写入存储
+func (tab *Tab) FetchAndStoreResponseBody(requestID network.RequestID, mimeType string) {
+	defer tab.WaitGroup.Done()
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	body, err := network.GetResponseBody(requestID).Do(tCtx)
+	if err != nil {
+		return
+	}
+	tab.StoreResponseBody(requestID.String(), mimeType, body)
+}
+
+// StoreResponseBody 将响应体写入存储,并在响应信息中记录响应体的hash
+func (tab *Tab) StoreResponseBody(networkID string, mimeType string, body []byte) {
+	if tab.config.BodyStore == nil {
+		return
+	}
+	hash, err := tab.config.BodyStore.Save(mimeType, body)
+	if err != nil || hash == "" {
+		return
+	}
+	tab.ResponseLock.Lock()
+	if info, ok := tab.ResponseInfos[networkID]; ok {
+		info.BodyHash = hash
+	}
+	tab.ResponseLock.Unlock()
+}
+
+// IsParsedResponseMime 判断是否为需要从响应体中解析链接的MIME类型
+func IsParsedResponseMime(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	return strings.Contains(mimeType, "text/css") || strings.Contains(mimeType, "application/javascript") || strings.Contains(mimeType, "text/html") || mimeType == "application/json"
+}
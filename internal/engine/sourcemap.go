@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"fmt"
+	"github.com/chromedp/cdproto/network"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"github.com/sairson/crawlergo/internal/sourcemap"
+	"path/filepath"
+	"strings"
+)
+
+// ProcessSourceMap 获取脚本引用的source map,还原原始源码后保存,并从源码中提取链接和敏感信息
+func (tab *Tab) ProcessSourceMap(scriptURL string, body string, headers network.Headers) {
+	responseHeaders := make(map[string]string, len(headers))
+	for key, value := range headers {
+		responseHeaders[key] = fmt.Sprint(value)
+	}
+	mapURL := sourcemap.FindURL(body, responseHeaders)
+	if mapURL == "" {
+		return
+	}
+	if !strings.HasPrefix(mapURL, "data:") {
+		base, err := urllib.GetURL(scriptURL, *tab.NavigateRequest.URL)
+		if err != nil {
+			return
+		}
+		u, err := urllib.GetURL(mapURL, *base)
+		if err != nil {
+			return
+		}
+		mapURL = u.String()
+	}
+	// 内联的source map使用脚本地址去重
+	key := mapURL
+	if strings.HasPrefix(mapURL, "data:") {
+		key = scriptURL + "#sourcemap"
+	}
+	if tab.config.SourceMapSet != nil && !tab.config.SourceMapSet.Add(key) {
+		return
+	}
+	m, err := sourcemap.Fetch(mapURL, tab.fetchHeaders(mapURL), tab.config.Proxy)
+	if err != nil {
+		return
+	}
+	sources := m.OriginalSources()
+	if len(sources) == 0 {
+		return
+	}
+	record := &sourcemap.Record{ScriptURL: scriptURL, MapURL: key}
+	if strings.HasPrefix(mapURL, "data:") {
+		record.MapURL = "data:"
+	}
+	if tab.config.SourceMapDir != "" {
+		host := tab.NavigateRequest.URL.Hostname()
+		if u, err := urllib.GetURL(scriptURL, *tab.NavigateRequest.URL); err == nil {
+			host = u.Hostname()
+		}
+		record.Dir = filepath.Join(tab.config.SourceMapDir, sourcemap.SafePath("", host))
+		record.Files, _ = sourcemap.SaveTree(record.Dir, sources)
+	} else {
+		for _, source := range sources {
+			record.Files = append(record.Files, source.Path)
+		}
+	}
+	record.Secrets = sourcemap.FindSecrets(sources)
+	for _, source := range sources {
+		// 第三方依赖中的链接与目标无关
+		if strings.Contains(source.Path, "node_modules/") {
+			continue
+		}
+		if ext := strings.ToLower(filepath.Ext(source.Path)); ext == ".js" || ext == ".mjs" || ext == ".cjs" {
+			tab.AddJSEndpoints(source.Content, enums2.FromSourceMap)
+		}
+		tab.ExtractSuspectURLs(source.Content, enums2.FromSourceMap)
+		tab.MatchCustomDefinedRegex(source.Content)
+	}
+	tab.Lock.Lock()
+	tab.SourceMapList = append(tab.SourceMapList, record)
+	tab.Lock.Unlock()
+}
+
+// fetchHeaders 直接发送请求时携带的请求头,请求地址在爬取范围内时保持与页面相同的Cookie和额外请求头,
+// 范围外的地址不携带凭据,Referer只保留来源
+func (tab *Tab) fetchHeaders(target string) map[string]string {
+	headers := map[string]string{}
+	u, err := urllib.GetURL(target)
+	if err != nil || !tab.inFetchScope(u) {
+		headers["Referer"] = tab.NavigateRequest.URL.Scheme + "://" + tab.NavigateRequest.URL.Host + "/"
+		return headers
+	}
+	if cookie, ok := tab.NavigateRequest.Headers["Cookie"]; ok {
+		headers["Cookie"] = fmt.Sprint(cookie)
+	}
+	for key, value := range tab.ExtraHeaders {
+		headers[key] = fmt.Sprint(value)
+	}
+	headers["Referer"] = tab.NavigateRequest.URL.String()
+	return headers
+}
+
+// inFetchScope 地址是否为限制的域名,或者是根域名及其子域名
+func (tab *Tab) inFetchScope(u *urllib.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	hostLimit := tab.config.HostLimit
+	if hostLimit == "" {
+		hostLimit = tab.NavigateRequest.URL.Host
+	}
+	if strings.EqualFold(u.Host, hostLimit) || strings.EqualFold(host, hostLimit) {
+		return true
+	}
+	rootDomain := strings.ToLower(tab.config.RootDomain)
+	return rootDomain != "" && (host == rootDomain || strings.HasSuffix(host, "."+rootDomain))
+}
@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"testing"
+)
+
+func TestFetchHeaders(t *testing.T) {
+	navURL, _ := urllib.GetURL("https://www.example.com/app/")
+	nav := httplib.GetCrawlerRequest("GET", navURL, httplib.OptionsCrawler{Headers: map[string]interface{}{"Cookie": "session=1"}})
+	tab := &Tab{
+		NavigateRequest: *nav,
+		ExtraHeaders:    map[string]interface{}{"Authorization": "Bearer token"},
+		config:          TabConfig{HostLimit: "www.example.com", RootDomain: "example.com"},
+	}
+	for target, credentials := range map[string]bool{
+		"https://www.example.com/static/app.js.map": true,
+		"https://cdn.example.com/app.js.map":        true,
+		"https://example.com/sw.js":                 true,
+		"https://cdn.other.com/app.js.map":          false,
+		"https://example.com.evil.com/app.js.map":   false,
+		"data:application/json;base64,e30=":         false,
+	} {
+		headers := tab.fetchHeaders(target)
+		if (headers["Cookie"] == "session=1" && headers["Authorization"] == "Bearer token") != credentials {
+			t.Fatalf("%s: expected credentials %v, got %v", target, credentials, headers)
+		}
+		if !credentials && headers["Referer"] != "https://www.example.com/" {
+			t.Fatalf("%s: expected origin referer, got %v", target, headers["Referer"])
+		}
+	}
+}
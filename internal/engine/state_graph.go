@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"sync"
+)
+
+// StateGraph 单页应用的状态图,节点为DOM状态的hash,边为触发状态转移的动作
+type StateGraph struct {
+	URL    string                `json:"url"`    // 探索的页面地址
+	Root   string                `json:"root"`   // 页面加载完成后的初始状态
+	States map[string]*StateNode `json:"states"` // 全部发现的状态
+	Edges  []StateEdge           `json:"edges"`  // 状态之间的转移
+	queue  []string              // 等待探索的状态,按照发现顺序广度优先
+	lock   sync.Mutex
+}
+
+// StateNode 一个DOM状态
+type StateNode struct {
+	Hash     string           `json:"hash"`     // DOM状态hash
+	Path     []httplib.Action `json:"path"`     // 从初始状态到达该状态的最短动作路径
+	Explored bool             `json:"explored"` // 是否已经探索过该状态上的动作
+}
+
+// StateEdge 在From状态上执行Action后到达To状态
+type StateEdge struct {
+	From   string         `json:"from"`
+	To     string         `json:"to"`
+	Action httplib.Action `json:"action"`
+}
+
+// NewStateGraph 新建一个状态图,初始状态加入探索队列
+func NewStateGraph(url string, root string) *StateGraph {
+	return &StateGraph{
+		URL:    url,
+		Root:   root,
+		States: map[string]*StateNode{root: {Hash: root}},
+		queue:  []string{root},
+	}
+}
+
+// AddTransition 记录一次状态转移,到达的状态未见过时加入探索队列并返回true
+func (g *StateGraph) AddTransition(from string, action httplib.Action, to string) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	fromState, ok := g.States[from]
+	if !ok {
+		return false
+	}
+	g.Edges = append(g.Edges, StateEdge{From: from, To: to, Action: action})
+	if _, ok = g.States[to]; ok {
+		return false
+	}
+	path := make([]httplib.Action, 0, len(fromState.Path)+1)
+	path = append(path, fromState.Path...)
+	path = append(path, action)
+	g.States[to] = &StateNode{Hash: to, Path: path}
+	g.queue = append(g.queue, to)
+	return true
+}
+
+// Next 取出下一个等待探索的状态
+func (g *StateGraph) Next() (*StateNode, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for len(g.queue) > 0 {
+		hash := g.queue[0]
+		g.queue = g.queue[1:]
+		state := g.States[hash]
+		if state.Explored {
+			continue
+		}
+		state.Explored = true
+		return state, true
+	}
+	return nil, false
+}
+
+// PathTo 返回到达指定状态需要重放的动作路径
+func (g *StateGraph) PathTo(hash string) ([]httplib.Action, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	state, ok := g.States[hash]
+	if !ok {
+		return nil, false
+	}
+	return state.Path, true
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"testing"
+)
+
+func TestStateGraph_BreadthFirst(t *testing.T) {
+	tabA := httplib.Action{Selector: "#tab-a", Label: "A"}
+	tabB := httplib.Action{Selector: "#tab-b", Label: "B"}
+	more := httplib.Action{Selector: "#more", Label: "more"}
+	graph := NewStateGraph("http://example.com/", "root")
+
+	state, ok := graph.Next()
+	if !ok || state.Hash != "root" {
+		t.Fatal("expected root state first")
+	}
+	if !graph.AddTransition("root", tabA, "a") || !graph.AddTransition("root", tabB, "b") {
+		t.Fatal("expected new states")
+	}
+	if graph.AddTransition("root", tabA, "a") || graph.AddTransition("unknown", tabA, "c") {
+		t.Fatal("expected known state or unknown source to be ignored")
+	}
+	state, _ = graph.Next()
+	if state.Hash != "a" {
+		t.Fatalf("expected state a, got %s", state.Hash)
+	}
+	graph.AddTransition("a", more, "a-more")
+	// 广度优先,b先于a-more
+	state, _ = graph.Next()
+	if state.Hash != "b" {
+		t.Fatalf("expected state b, got %s", state.Hash)
+	}
+	path, ok := graph.PathTo("a-more")
+	if !ok || len(path) != 2 || path[0] != tabA || path[1] != more {
+		t.Fatalf("unexpected path: %v", path)
+	}
+	state, _ = graph.Next()
+	if state.Hash != "a-more" {
+		t.Fatalf("expected state a-more, got %s", state.Hash)
+	}
+	if _, ok = graph.Next(); ok {
+		t.Fatal("expected queue to be empty")
+	}
+	if len(graph.Edges) != 4 {
+		t.Fatalf("expected 4 edges, got %d", len(graph.Edges))
+	}
+}
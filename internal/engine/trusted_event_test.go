@@ -0,0 +1,18 @@
+package engine
+
+import "testing"
+
+func TestTrustedEventKinds(t *testing.T) {
+	kinds := trustedEventKinds([]string{"onmouseover", "click", " KeyUp ", "dbclick"})
+	for _, kind := range []string{trustedHover, trustedClick, trustedKey, trustedDblClick} {
+		if !kinds[kind] {
+			t.Fatalf("expected %s in %v", kind, kinds)
+		}
+	}
+	if kinds[trustedFocus] {
+		t.Fatalf("unexpected focus in %v", kinds)
+	}
+	if len(trustedEventKinds([]string{"onload", "onresize", ""})) != 0 {
+		t.Fatal("expected page level events to be ignored")
+	}
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 这里处理文件上传需要的样例文件,样例文件在临时目录中按需生成
+
+// uploadSample 一种样例文件
+type uploadSample struct {
+	ext      string
+	mimeType string
+	content  func() []byte
+}
+
+// uploadSamples 支持生成的样例文件,第一个为默认的上传文件
+var uploadSamples = []uploadSample{
+	{ext: "png", mimeType: "image/png", content: samplePNG},
+	{ext: "jpg", mimeType: "image/jpeg", content: sampleJPEG},
+	{ext: "gif", mimeType: "image/gif", content: sampleGIF},
+	{ext: "pdf", mimeType: "application/pdf", content: samplePDF},
+	{ext: "txt", mimeType: "text/plain", content: sampleText("crawlergo upload sample\n")},
+	{ext: "csv", mimeType: "text/csv", content: sampleText("name,value\ncrawlergo,1\n")},
+	{ext: "json", mimeType: "application/json", content: sampleText(`{"name":"crawlergo"}`)},
+	{ext: "xml", mimeType: "application/xml", content: sampleText(`<?xml version="1.0"?><name>crawlergo</name>`)},
+	{ext: "svg", mimeType: "image/svg+xml", content: sampleText(`<svg xmlns="http://www.w3.org/2000/svg" width="1" height="1"/>`)},
+	{ext: "zip", mimeType: "application/zip", content: sampleZIP},
+}
+
+var uploadSampleLock sync.Mutex
+
+// UploadDir 保存样例文件的临时目录,第一次需要样例文件时才创建
+type UploadDir struct {
+	path string
+	lock sync.Mutex
+}
+
+// NewUploadDir 新建样例文件目录,此时不会创建临时目录
+func NewUploadDir() *UploadDir {
+	return &UploadDir{}
+}
+
+// Path 返回临时目录,不存在时创建
+func (d *UploadDir) Path() (string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.path != "" {
+		return d.path, nil
+	}
+	path, err := os.MkdirTemp("", "crawlergo-upload-")
+	if err != nil {
+		return "", err
+	}
+	d.path = path
+	return path, nil
+}
+
+// SampleFile 根据文件输入框的accept属性获取样例文件
+func (d *UploadDir) SampleFile(accept string) (string, error) {
+	dir, err := d.Path()
+	if err != nil {
+		return "", err
+	}
+	return GetUploadSampleFile(dir, accept)
+}
+
+// Remove 删除已经创建的临时目录
+func (d *UploadDir) Remove() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.path == "" {
+		return nil
+	}
+	err := os.RemoveAll(d.path)
+	d.path = ""
+	return err
+}
+
+// GetUploadSampleFile 根据文件输入框的accept属性选择样例文件,文件不存在时在目录中生成
+func GetUploadSampleFile(dir string, accept string) (string, error) {
+	sample := matchUploadSample(accept)
+	path := filepath.Join(dir, "sample."+sample.ext)
+	uploadSampleLock.Lock()
+	defer uploadSampleLock.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return path, os.WriteFile(path, sample.content(), 0644)
+}
+
+// matchUploadSample 按照accept中的顺序匹配,支持 .pdf、image/png 和 image/* 三种写法
+func matchUploadSample(accept string) uploadSample {
+	for _, item := range strings.Split(strings.ToLower(accept), ",") {
+		item = strings.TrimSpace(item)
+		for _, sample := range uploadSamples {
+			switch {
+			case item == "."+sample.ext:
+				return sample
+			case item == sample.mimeType:
+				return sample
+			case strings.HasSuffix(item, "/*") && strings.HasPrefix(sample.mimeType, strings.TrimSuffix(item, "*")):
+				return sample
+			}
+		}
+		if item == ".jpeg" {
+			return uploadSamples[1]
+		}
+	}
+	return uploadSamples[0]
+}
+
+func sampleImage() image.Image {
+	img := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.White, color.Black})
+	img.SetColorIndex(0, 0, 0)
+	return img
+}
+
+func samplePNG() []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, sampleImage())
+	return buf.Bytes()
+}
+
+func sampleJPEG() []byte {
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, sampleImage(), nil)
+	return buf.Bytes()
+}
+
+func sampleGIF() []byte {
+	var buf bytes.Buffer
+	_ = gif.Encode(&buf, sampleImage(), nil)
+	return buf.Bytes()
+}
+
+func samplePDF() []byte {
+	return []byte("%PDF-1.4\n" +
+		"1 0 obj<</Type/Catalog/Pages 2 0 R>>endobj\n" +
+		"2 0 obj<</Type/Pages/Kids[3 0 R]/Count 1>>endobj\n" +
+		"3 0 obj<</Type/Page/Parent 2 0 R/MediaBox[0 0 72 72]>>endobj\n" +
+		"trailer<</Root 1 0 R>>\n%%EOF\n")
+}
+
+func sampleZIP() []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	if f, err := writer.Create("sample.txt"); err == nil {
+		_, _ = f.Write([]byte("crawlergo upload sample\n"))
+	}
+	_ = writer.Close()
+	return buf.Bytes()
+}
+
+func sampleText(text string) func() []byte {
+	return func() []byte {
+		return []byte(text)
+	}
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetUploadSampleFile(t *testing.T) {
+	dir := t.TempDir()
+	for accept, name := range map[string]string{
+		"":                      "sample.png",
+		"image/*":               "sample.png",
+		".jpeg,.jpg":            "sample.jpg",
+		"application/pdf":       "sample.pdf",
+		"text/csv, .txt":        "sample.csv",
+		"video/mp4, .zip, .rar": "sample.zip",
+		"application/x-unknown": "sample.png",
+	} {
+		path, err := GetUploadSampleFile(dir, accept)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Base(path) != name {
+			t.Fatalf("accept %q: expected %s, got %s", accept, name, filepath.Base(path))
+		}
+	}
+	f, err := os.Open(filepath.Join(dir, "sample.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = png.Decode(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUploadDir(t *testing.T) {
+	dir := NewUploadDir()
+	if dir.path != "" {
+		t.Fatal("upload dir should not be created before it is needed")
+	}
+	if err := dir.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	path, err := dir.SampleFile(".pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := dir.SampleFile("application/pdf"); again != path {
+		t.Fatalf("expected the same sample file, got %s and %s", path, again)
+	}
+	if err = dir.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Fatalf("upload dir should be removed: %v", err)
+	}
+}
+
+func TestIsUploadRequest(t *testing.T) {
+	u, _ := urllib.GetURL("http://testphp.vulnweb.com/upload.php")
+	multipartBody := func(files ...string) (string, string) {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		_ = writer.WriteField("title", "image")
+		for i, name := range files {
+			part, _ := writer.CreateFormFile(fmt.Sprintf("file%d", i), name)
+			_, _ = part.Write([]byte("content"))
+		}
+		_ = writer.Close()
+		return buf.String(), writer.FormDataContentType()
+	}
+	for _, item := range []struct {
+		files    []string
+		expected bool
+	}{
+		{files: []string{"sample.png"}, expected: true},
+		{files: []string{"sample.png", ""}, expected: true},
+		{files: []string{"", "sample.png"}, expected: true},
+		{files: []string{""}, expected: false},
+		{files: nil, expected: false},
+	} {
+		body, contentType := multipartBody(item.files...)
+		req := httplib.GetCrawlerRequest("POST", u, httplib.OptionsCrawler{Headers: map[string]interface{}{"Content-Type": contentType}, PostData: body})
+		if IsUploadRequest(req) != item.expected {
+			t.Fatalf("files %q: expected %v", item.files, item.expected)
+		}
+	}
+	req := httplib.GetCrawlerRequest("POST", u, httplib.OptionsCrawler{Headers: map[string]interface{}{"Content-Type": "application/x-www-form-urlencoded"}, PostData: `filename="sample.png"`})
+	if IsUploadRequest(req) {
+		t.Fatal("urlencoded request is not an upload")
+	}
+}
@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/network"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 这里处理WebSocket的捕获,记录握手的请求头和响应头,以及每个连接有限数量的消息帧,并推断消息的结构
+
+// WebSocket消息帧的方向
+const (
+	WebSocketSent     = "sent"
+	WebSocketReceived = "received"
+)
+
+// WebSocketRecord 一个WebSocket连接的记录
+type WebSocketRecord struct {
+	URL             string            `json:"url"`              // 连接地址
+	PageURL         string            `json:"page_url"`         // 发起连接的页面
+	Status          int               `json:"status"`           // 握手响应的状态码
+	RequestHeaders  map[string]string `json:"request_headers"`  // 握手的请求头
+	ResponseHeaders map[string]string `json:"response_headers"` // 握手的响应头
+	Frames          []WebSocketFrame  `json:"frames"`           // 消息帧,超过数量限制后不再记录
+	FrameCount      int               `json:"frame_count"`      // 消息帧的总数,包括没有记录的
+	Schemas         map[string]int    `json:"schemas"`          // 消息结构以及出现的次数
+	Closed          bool              `json:"closed"`           // 连接是否已经关闭
+	maxFrames       int
+}
+
+// WebSocketFrame 一条WebSocket消息帧
+type WebSocketFrame struct {
+	Direction string `json:"direction"` // sent 或 received
+	Opcode    int    `json:"opcode"`    // 1为文本消息,2为base64编码的二进制消息
+	Payload   string `json:"payload"`   // 消息内容,超过长度限制时截断
+	Truncated bool   `json:"truncated"` // 消息内容是否被截断
+	Time      string `json:"time"`
+}
+
+// HandleWebSocketCreated 新建WebSocket连接记录
+func (tab *Tab) HandleWebSocketCreated(v *network.EventWebSocketCreated) {
+	record := &WebSocketRecord{
+		URL:             v.URL,
+		PageURL:         tab.NavigateRequest.URL.String(),
+		RequestHeaders:  map[string]string{},
+		ResponseHeaders: map[string]string{},
+		Schemas:         map[string]int{},
+		maxFrames:       tab.config.WebSocketMaxFrames,
+	}
+	tab.WebSocketLock.Lock()
+	tab.WebSockets[v.RequestID.String()] = record
+	tab.WebSocketList = append(tab.WebSocketList, record)
+	tab.WebSocketLock.Unlock()
+}
+
+// HandleWebSocketHandshakeRequest 记录握手的请求头
+func (tab *Tab) HandleWebSocketHandshakeRequest(v *network.EventWebSocketWillSendHandshakeRequest) {
+	if v.Request == nil {
+		return
+	}
+	tab.WebSocketLock.Lock()
+	defer tab.WebSocketLock.Unlock()
+	if record, ok := tab.WebSockets[v.RequestID.String()]; ok {
+		for key, value := range v.Request.Headers {
+			record.RequestHeaders[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// HandleWebSocketHandshakeResponse 记录握手的状态码和响应头
+func (tab *Tab) HandleWebSocketHandshakeResponse(v *network.EventWebSocketHandshakeResponseReceived) {
+	if v.Response == nil {
+		return
+	}
+	tab.WebSocketLock.Lock()
+	defer tab.WebSocketLock.Unlock()
+	if record, ok := tab.WebSockets[v.RequestID.String()]; ok {
+		record.Status = int(v.Response.Status)
+		for key, value := range v.Response.Headers {
+			record.ResponseHeaders[key] = fmt.Sprint(value)
+		}
+		// 握手请求事件中不一定包含浏览器最终发送的请求头
+		for key, value := range v.Response.RequestHeaders {
+			record.RequestHeaders[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// HandleWebSocketFrame 记录发送或接收的消息帧
+func (tab *Tab) HandleWebSocketFrame(requestID network.RequestID, direction string, frame *network.WebSocketFrame) {
+	if frame == nil {
+		return
+	}
+	tab.WebSocketLock.Lock()
+	defer tab.WebSocketLock.Unlock()
+	if record, ok := tab.WebSockets[requestID.String()]; ok {
+		record.AddFrame(direction, int(frame.Opcode), frame.PayloadData)
+	}
+}
+
+// HandleWebSocketClosed 标记连接已经关闭
+func (tab *Tab) HandleWebSocketClosed(v *network.EventWebSocketClosed) {
+	tab.WebSocketLock.Lock()
+	defer tab.WebSocketLock.Unlock()
+	if record, ok := tab.WebSockets[v.RequestID.String()]; ok {
+		record.Closed = true
+	}
+}
+
+// AddFrame 添加一条消息帧,全部消息都参与结构推断,但只记录限制数量以内的消息帧
+func (record *WebSocketRecord) AddFrame(direction string, opcode int, payload string) {
+	record.FrameCount++
+	if opcode == 1 || opcode == 2 {
+		schema := direction + " " + InferMessageSchema(opcode, payload)
+		if _, ok := record.Schemas[schema]; ok || len(record.Schemas) < enums2.WebSocketMaxSchemas {
+			record.Schemas[schema]++
+		}
+	}
+	if record.maxFrames > 0 && len(record.Frames) >= record.maxFrames {
+		return
+	}
+	frame := WebSocketFrame{
+		Direction: direction,
+		Opcode:    opcode,
+		Payload:   payload,
+		Time:      time.Now().Format(time.RFC3339),
+	}
+	if len(payload) > enums2.WebSocketMaxPayload {
+		frame.Payload = truncateUTF8(payload, enums2.WebSocketMaxPayload)
+		frame.Truncated = true
+	}
+	record.Frames = append(record.Frames, frame)
+}
+
+// InferMessageSchema 推断消息的结构,json消息返回字段名和类型组成的结构,值不参与结构
+func InferMessageSchema(opcode int, payload string) string {
+	if opcode != 1 {
+		return "binary"
+	}
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return "text"
+	}
+	return jsonSchema(value, 0)
+}
+
+// jsonSchema 生成json值的结构描述,对象的字段按照名称排序,数组只取第一个元素的结构
+func jsonSchema(value interface{}, depth int) string {
+	if depth > 5 {
+		return "any"
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var fields []string
+		for _, key := range keys {
+			name, _ := json.Marshal(key)
+			fields = append(fields, string(name)+":"+jsonSchema(v[key], depth+1))
+		}
+		return "{" + strings.Join(fields, ",") + "}"
+	case []interface{}:
+		if len(v) == 0 {
+			return "[]"
+		}
+		return "[" + jsonSchema(v[0], depth+1) + "]"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return "any"
+}
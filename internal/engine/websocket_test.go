@@ -0,0 +1,49 @@
+package engine
+
+import (
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestInferMessageSchema(t *testing.T) {
+	for _, item := range []struct {
+		opcode  int
+		payload string
+		schema  string
+	}{
+		{1, `{"type":"chat","id":12,"data":{"to":[1,2],"read":false}}`, `{"data":{"read":boolean,"to":[number]},"id":number,"type":string}`},
+		{1, `{"id":99,"type":"ping","data":{"read":true,"to":[]}}`, `{"data":{"read":boolean,"to":[]},"id":number,"type":string}`},
+		{1, `[{"a":null}]`, `[{"a":null}]`},
+		{1, `42["message",{"x":1}]`, "text"},
+		{1, `hello`, "text"},
+		{2, `AAEC`, "binary"},
+	} {
+		if schema := InferMessageSchema(item.opcode, item.payload); schema != item.schema {
+			t.Fatalf("%s: expected %s, got %s", item.payload, item.schema, schema)
+		}
+	}
+}
+
+func TestWebSocketRecord_AddFrame(t *testing.T) {
+	record := &WebSocketRecord{Schemas: map[string]int{}, maxFrames: 2}
+	record.AddFrame(WebSocketSent, 1, `{"op":"subscribe"}`)
+	record.AddFrame(WebSocketReceived, 1, strings.Repeat("a", enums2.WebSocketMaxPayload+1))
+	record.AddFrame(WebSocketSent, 1, `{"op":"unsubscribe"}`)
+	if record.FrameCount != 3 || len(record.Frames) != 2 {
+		t.Fatalf("expected 3 frames with 2 recorded, got %d %d", record.FrameCount, len(record.Frames))
+	}
+	if !record.Frames[1].Truncated || len(record.Frames[1].Payload) != enums2.WebSocketMaxPayload {
+		t.Fatal("expected long payload to be truncated")
+	}
+	// 截断位置在多字节字符中间时回退到字符边界
+	record2 := &WebSocketRecord{Schemas: map[string]int{}}
+	record2.AddFrame(WebSocketReceived, 1, strings.Repeat("中", enums2.WebSocketMaxPayload/3+1))
+	if payload := record2.Frames[0].Payload; !record2.Frames[0].Truncated || !utf8.ValidString(payload) || len(payload) != enums2.WebSocketMaxPayload-1 {
+		t.Fatalf("expected payload truncated on rune boundary, got %d bytes", len(payload))
+	}
+	if record.Schemas[`sent {"op":string}`] != 2 || record.Schemas["received text"] != 1 {
+		t.Fatalf("unexpected schemas %v", record.Schemas)
+	}
+}
@@ -0,0 +1,287 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/chromedp/cdproto/page"
+	enums2 "github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"github.com/sairson/crawlergo/internal/engine/requests"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 这里处理service worker、worker脚本和web app manifest,
+// service worker中通常带有预缓存列表,包含了站点的全部页面和静态资源
+
+// workerMaxDepth importScripts 最多跟随的层数
+const workerMaxDepth = 3
+
+var (
+	// importScriptsRegex worker中的 importScripts 调用
+	importScriptsRegex = regexp.MustCompile(`importScripts\s*\(([^)]*)\)`)
+	// stringLiteralRegex 字符串字面量
+	stringLiteralRegex = regexp.MustCompile("[\"'`]([^\"'`\\s]+)[\"'`]")
+	// precacheMarkerRegex Workbox、sw-precache 和 cache.addAll 等预缓存列表的位置,列表为其后第一个非空数组
+	precacheMarkerRegex = regexp.MustCompile(`precacheAndRoute|__precacheManifest|precacheConfig|\.addAll\s*\(`)
+	// precacheURLRegex 预缓存条目中的url属性 {url: "/index.html", revision: "..."}
+	precacheURLRegex = regexp.MustCompile(`["']?url["']?\s*:\s*["']([^"']+)["']`)
+	// precacheItemRegex 数组中直接出现的字符串条目,包括sw-precache的 ["/index.html", "hash"]
+	precacheItemRegex = regexp.MustCompile(`[\[,]\s*["']([^"'\s]+)["']\s*(?:,|\])`)
+	// revisionRegex 预缓存条目中的版本hash
+	revisionRegex = regexp.MustCompile(`^[0-9a-fA-F]{8,}$`)
+)
+
+// ParseImportScripts 解析worker脚本中 importScripts 引入的脚本
+func ParseImportScripts(js string) []string {
+	var scripts []string
+	for _, match := range importScriptsRegex.FindAllStringSubmatch(js, -1) {
+		for _, item := range stringLiteralRegex.FindAllStringSubmatch(match[1], -1) {
+			scripts = appendUnique(scripts, item[1])
+		}
+	}
+	return scripts
+}
+
+// ParsePrecacheManifest 解析service worker中的预缓存列表
+func ParsePrecacheManifest(js string) []string {
+	var urls []string
+	for _, loc := range precacheMarkerRegex.FindAllStringIndex(js, -1) {
+		list := precacheList(js, loc[1])
+		for _, match := range precacheURLRegex.FindAllStringSubmatch(list, -1) {
+			urls = appendUnique(urls, match[1])
+		}
+		// 条目之间以逗号分隔,这里会重叠匹配,逐个位置查找
+		for i := 0; i < len(list); {
+			loc := precacheItemRegex.FindStringSubmatchIndex(list[i:])
+			if loc == nil {
+				break
+			}
+			item := list[i+loc[2] : i+loc[3]]
+			if !revisionRegex.MatchString(item) && strings.ContainsAny(item, "/.") {
+				urls = appendUnique(urls, item)
+			}
+			i += loc[3]
+		}
+	}
+	return urls
+}
+
+// precacheList 获取标记之后200个字符内的第一个非空数组,例如 (self.__precacheManifest || []).concat([...])
+func precacheList(js string, start int) string {
+	end := start + 200
+	if end > len(js) {
+		end = len(js)
+	}
+	for i := start; i < end; i++ {
+		if js[i] != '[' {
+			continue
+		}
+		if list := bracketSlice(js, i); len(list) > 2 && strings.TrimSpace(list[1:len(list)-1]) != "" {
+			return list
+		}
+	}
+	return ""
+}
+
+// bracketSlice 从 [ 开始截取到匹配的 ] 为止,忽略字符串中的括号
+func bracketSlice(s string, start int) string {
+	depth := 0
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return s[start : i+1]
+			}
+		}
+	}
+	return s[start:]
+}
+
+// ManifestEntry manifest中的一个地址
+type ManifestEntry struct {
+	Field  string   // 所在的字段,例如 start_url、icons
+	URL    string   // 地址,相对地址相对于manifest的地址
+	Method string   // 请求方法,只有share_target可能为POST
+	Params []string // share_target的参数名
+}
+
+// webAppManifest manifest中包含地址的字段
+type webAppManifest struct {
+	StartURL string `json:"start_url"`
+	Scope    string `json:"scope"`
+	Icons    []struct {
+		Src string `json:"src"`
+	} `json:"icons"`
+	Screenshots []struct {
+		Src string `json:"src"`
+	} `json:"screenshots"`
+	Shortcuts []struct {
+		URL   string `json:"url"`
+		Icons []struct {
+			Src string `json:"src"`
+		} `json:"icons"`
+	} `json:"shortcuts"`
+	ProtocolHandlers []struct {
+		URL string `json:"url"`
+	} `json:"protocol_handlers"`
+	ShareTarget *struct {
+		Action string                 `json:"action"`
+		Method string                 `json:"method"`
+		Params map[string]interface{} `json:"params"`
+	} `json:"share_target"`
+}
+
+// ParseManifest 解析web app manifest中的地址
+func ParseManifest(data string) ([]ManifestEntry, error) {
+	var manifest webAppManifest
+	if err := json.Unmarshal([]byte(data), &manifest); err != nil {
+		return nil, err
+	}
+	var entries []ManifestEntry
+	add := func(field string, u string) {
+		if u = strings.TrimSpace(u); u != "" {
+			entries = append(entries, ManifestEntry{Field: field, URL: u, Method: enums2.GET})
+		}
+	}
+	add("start_url", manifest.StartURL)
+	add("scope", manifest.Scope)
+	for _, icon := range manifest.Icons {
+		add("icons", icon.Src)
+	}
+	for _, screenshot := range manifest.Screenshots {
+		add("screenshots", screenshot.Src)
+	}
+	for _, shortcut := range manifest.Shortcuts {
+		add("shortcuts", shortcut.URL)
+		for _, icon := range shortcut.Icons {
+			add("icons", icon.Src)
+		}
+	}
+	for _, handler := range manifest.ProtocolHandlers {
+		// 处理程序地址中的 %s 为协议地址
+		add("protocol_handlers", strings.ReplaceAll(handler.URL, "%s", "web%2Bcrawlergo%3A1"))
+	}
+	if target := manifest.ShareTarget; target != nil && target.Action != "" {
+		entry := ManifestEntry{Field: "share_target", URL: target.Action, Method: enums2.GET}
+		if strings.EqualFold(target.Method, enums2.POST) {
+			entry.Method = enums2.POST
+		}
+		for _, key := range []string{"title", "text", "url"} {
+			if name, ok := target.Params[key].(string); ok && name != "" {
+				entry.Params = append(entry.Params, name)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ProcessWorkerScript 获取worker脚本,解析其中的预缓存列表、importScripts 和请求
+func (tab *Tab) ProcessWorkerScript(scriptURL string, source string, depth int) {
+	defer tab.WaitGroup.Done()
+	u, err := urllib.GetURL(scriptURL, *tab.NavigateRequest.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	if tab.config.WorkerScriptSet != nil && !tab.config.WorkerScriptSet.Add(u.String()) {
+		return
+	}
+	headers := tab.fetchHeaders(u.String())
+	headers["Range"] = "bytes=0-"
+	// service worker脚本请求带有该请求头
+	if source == enums2.FromServiceWorker {
+		headers["Service-Worker"] = "script"
+	}
+	resp, err := requests.Get(u.String(), headers, &requests.RequestOptions{
+		Timeout: 10,
+		Proxy:   tab.config.Proxy,
+	})
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return
+	}
+	js := string(resp.Body)
+	// 预缓存的地址相对于service worker脚本
+	for _, item := range ParsePrecacheManifest(js) {
+		if target, err := urllib.GetURL(item, *u); err == nil {
+			tab.AddResultFormCustomUrl(enums2.GET, target.String(), enums2.FromPrecache)
+		}
+	}
+	if depth < workerMaxDepth {
+		for _, item := range ParseImportScripts(js) {
+			if target, err := urllib.GetURL(item, *u); err == nil {
+				tab.AddResultFormCustomUrl(enums2.GET, target.String(), enums2.FromWorker)
+				tab.WaitGroup.Add(1)
+				go tab.ProcessWorkerScript(target.String(), enums2.FromWorker, depth+1)
+			}
+		}
+	}
+	tab.AddJSEndpoints(js, source)
+	tab.ExtractSuspectURLs(js, source)
+}
+
+// CollectAppManifest 通过浏览器获取页面的web app manifest,添加其中的地址
+func (tab *Tab) CollectAppManifest() {
+	ctx := tab.GetCDPExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	manifestURL, _, data, _, err := page.GetAppManifest().Do(tCtx)
+	if err != nil || manifestURL == "" || data == "" {
+		return
+	}
+	base, err := urllib.GetURL(manifestURL, *tab.NavigateRequest.URL)
+	if err != nil {
+		return
+	}
+	tab.AddResultFormCustomUrl(enums2.GET, base.String(), enums2.FromManifest)
+	entries, err := ParseManifest(data)
+	if err != nil {
+		return
+	}
+	f := &FillForm{Tab: tab}
+	for _, entry := range entries {
+		target, err := urllib.GetURL(entry.URL, *base)
+		if err != nil {
+			continue
+		}
+		if len(entry.Params) == 0 {
+			tab.AddResultFormCustomUrl(entry.Method, target.String(), enums2.FromManifest)
+			continue
+		}
+		values := url.Values{}
+		for _, name := range entry.Params {
+			values.Set(name, f.GetMatchInputText(name))
+		}
+		if entry.Method == enums2.POST {
+			tab.AddResultFormCustomRequest(enums2.POST, target.String(), values.Encode(), enums2.URLENCODED, enums2.FromManifest)
+		} else {
+			tab.AddResultFormCustomUrl(enums2.GET, appendQuery(target.String(), values.Encode()), enums2.FromManifest)
+		}
+	}
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseImportScripts(t *testing.T) {
+	js := `importScripts("https://storage.googleapis.com/workbox-cdn/releases/6.5.4/workbox-sw.js");
+importScripts('/precache-manifest.1a2b.js', "./sw-helper.js");`
+	scripts := ParseImportScripts(js)
+	expected := []string{"https://storage.googleapis.com/workbox-cdn/releases/6.5.4/workbox-sw.js", "/precache-manifest.1a2b.js", "./sw-helper.js"}
+	if !reflect.DeepEqual(scripts, expected) {
+		t.Fatalf("unexpected scripts %v", scripts)
+	}
+}
+
+func TestParsePrecacheManifest(t *testing.T) {
+	for _, item := range []struct {
+		js   string
+		urls []string
+	}{
+		{
+			// Workbox v5+
+			`workbox.precaching.precacheAndRoute([{url:"/index.html",revision:"3f2a9c1d5e"},{"revision":null,"url":"static/js/main.8a1b.js"},"/offline"]);`,
+			[]string{"/index.html", "static/js/main.8a1b.js", "/offline"},
+		},
+		{
+			// Workbox v3/v4 生成的 precache-manifest
+			`self.__precacheManifest = (self.__precacheManifest || []).concat([{"revision": "0f1e2d3c4b5a", "url": "/about.html"}]);`,
+			[]string{"/about.html"},
+		},
+		{
+			// sw-precache
+			`var precacheConfig = [["/admin/index.html","9a8b7c6d5e4f3a2b"],["/login.html","1234567890abcdef"]];`,
+			[]string{"/admin/index.html", "/login.html"},
+		},
+		{
+			`caches.open("v1").then(function(cache){return cache.addAll(["/", "/app.css", "/help/faq.html"])});`,
+			[]string{"/", "/app.css", "/help/faq.html"},
+		},
+		{`self.addEventListener("fetch", function(e){})`, nil},
+	} {
+		if urls := ParsePrecacheManifest(item.js); !reflect.DeepEqual(urls, item.urls) {
+			t.Fatalf("unexpected urls %v for %s", urls, item.js)
+		}
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	data := `{"start_url":"./?utm_source=homescreen","scope":"/app/","icons":[{"src":"icons/192.png"}],
+"shortcuts":[{"name":"Orders","url":"/orders","icons":[{"src":"/icons/orders.png"}]}],
+"share_target":{"action":"/share","method":"POST","enctype":"multipart/form-data","params":{"title":"name","text":"description","files":[{"name":"file"}]}}}`
+	entries, err := ParseManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, entry := range entries {
+		fields = append(fields, entry.Field+" "+entry.URL)
+	}
+	expected := []string{"start_url ./?utm_source=homescreen", "scope /app/", "icons icons/192.png", "shortcuts /orders", "icons /icons/orders.png", "share_target /share"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("unexpected entries %v", fields)
+	}
+	share := entries[len(entries)-1]
+	if share.Method != "POST" || !reflect.DeepEqual(share.Params, []string{"name", "description"}) {
+		t.Fatalf("unexpected share target %+v", share)
+	}
+	if _, err = ParseManifest("<html>"); err == nil {
+		t.Fatal("expected error for invalid manifest")
+	}
+}
@@ -35,6 +35,8 @@ func (expression *CrawlerExpression) Robots(navRequest httplib.RequestCrawler, c
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return result, errors.Wrap(err, fmt.Sprintf("status code is %v", resp.StatusCode))
 	}
+	// 从robots.txt中解析出来的请求都带上robots.txt的响应信息
+	info := httplib.NewResponseInfo(resp)
 	// 我们查找url列表
 	for _, v := range regexp.MustCompile(`(?:Disallow|Allow):.*?(/.+)`).FindAllString(resp.ToText(), -1) {
 		url, err := urllib.GetURL(regexp.MustCompile(`(/.+)`).FindString(strings.TrimSpace(v)), *navRequest.URL)
@@ -43,6 +45,7 @@ func (expression *CrawlerExpression) Robots(navRequest httplib.RequestCrawler, c
 		}
 		request := httplib.GetCrawlerRequest(enums2.GET, url)
 		request.Source = enums2.FromRobots
+		request.Response = info
 		_ = callback(request)
 		result = append(result, request)
 	}
@@ -69,6 +72,8 @@ func (expression *CrawlerExpression) Sitemap(navRequest httplib.RequestCrawler,
 	if err := xml.NewDecoder(strings.NewReader(resp.ToText())).Decode(&sitemap); err != nil {
 		return result, errors.Wrap(err, "could not decode xml")
 	}
+	// 从sitemap.xml中解析出来的请求都带上sitemap.xml的响应信息
+	info := httplib.NewResponseInfo(resp)
 	for _, v := range sitemap.URLs {
 		url, err := urllib.GetURL(strings.Trim(v.Loc, " \t\n"), *navRequest.URL)
 		if err != nil {
@@ -76,6 +81,7 @@ func (expression *CrawlerExpression) Sitemap(navRequest httplib.RequestCrawler,
 		}
 		request := httplib.GetCrawlerRequest(enums2.GET, url)
 		request.Source = enums2.FromSitemap
+		request.Response = info
 		_ = callback(request)
 		result = append(result, request)
 	}
@@ -87,6 +93,7 @@ func (expression *CrawlerExpression) Sitemap(navRequest httplib.RequestCrawler,
 		}
 		request := httplib.GetCrawlerRequest(enums2.GET, url)
 		request.Source = enums2.FromSitemap
+		request.Response = info
 		_ = callback(request)
 		result = append(result, request)
 	}
@@ -112,7 +119,7 @@ func (expression *CrawlerExpression) DoDictRequestFuzz(navRequest httplib.Reques
 	for _, path := range paths {
 		path = strings.TrimPrefix(path, "/")
 		path = strings.TrimSuffix(path, "\n")
-		task := FuzzSingle{request: navRequest, path: path, fuzzWaitGroup: &expression.FuzzWaitGroup, fuzzValidateUrlList: expression.FuzzValidateUrlList}
+		task := FuzzSingle{request: navRequest, path: path, fuzzWaitGroup: &expression.FuzzWaitGroup, fuzzValidateUrlList: expression.FuzzValidateUrlList, fuzzResponseMap: &expression.FuzzResponseMap}
 		expression.FuzzWaitGroup.Add(1)
 		go func() {
 			err := pool.Submit(task.DoHttpRequest)
@@ -129,6 +136,9 @@ func (expression *CrawlerExpression) DoDictRequestFuzz(navRequest httplib.Reques
 		}
 		req := httplib.GetCrawlerRequest(enums2.GET, url)
 		req.Source = enums2.FromFuzz
+		if info, ok := expression.FuzzResponseMap.Load(_url); ok {
+			req.Response = info.(*httplib.ResponseInfo)
+		}
 		_ = callback(req)
 		result = append(result, req)
 	}
@@ -144,8 +154,10 @@ func (single *FuzzSingle) DoHttpRequest() {
 		return
 	}
 	//fmt.Println(fmt.Sprintf(`%s://%s/%s`, single.request.URL.Scheme, single.request.URL.Host, single.path), resp.StatusCode)
+	fuzzURL := fmt.Sprintf(`%s://%s/%s`, single.request.URL.Scheme, single.request.URL.Host, single.path)
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		single.fuzzValidateUrlList.Add(fmt.Sprintf(`%s://%s/%s`, single.request.URL.Scheme, single.request.URL.Host, single.path))
+		single.fuzzResponseMap.Store(fuzzURL, httplib.NewResponseInfo(resp))
+		single.fuzzValidateUrlList.Add(fuzzURL)
 	} else if resp.StatusCode == 301 {
 		Locations := resp.Header["Location"]
 		if len(Locations) <= 0 {
@@ -156,7 +168,8 @@ func (single *FuzzSingle) DoHttpRequest() {
 			return
 		}
 		if redirectUrl.Host == single.request.URL.Host {
-			single.fuzzValidateUrlList.Add(fmt.Sprintf(`%s://%s/%s`, single.request.URL.Scheme, single.request.URL.Host, single.path))
+			single.fuzzResponseMap.Store(fuzzURL, httplib.NewResponseInfo(resp))
+			single.fuzzValidateUrlList.Add(fuzzURL)
 		}
 	}
 }
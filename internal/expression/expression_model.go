@@ -9,6 +9,7 @@ import (
 type CrawlerExpression struct {
 	FuzzWaitGroup       sync.WaitGroup
 	FuzzValidateUrlList mapset.Set
+	FuzzResponseMap     sync.Map // fuzz命中的url对应的响应信息
 }
 
 type Sitemap struct {
@@ -25,4 +26,5 @@ type FuzzSingle struct {
 	fuzzWaitGroup       *sync.WaitGroup
 	request             httplib.RequestCrawler
 	fuzzValidateUrlList mapset.Set
+	fuzzResponseMap     *sync.Map
 }
@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"fmt"
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 内置的过滤模式
+const (
+	ModeSimple = "simple" // 普通模式,只做域名、去重和静态资源过滤
+	ModeSmart  = "smart"  // 智能模式,对参数值和路径做标记后去重
+	ModeStrict = "strict" // 严格模式,在智能模式的基础上将更多的参数值视为伪静态
+)
+
+// Filter 请求过滤器,爬取目标和tab页发现的请求都会经过同一个过滤器,会被多个tab并发调用
+type Filter interface {
+	// DoFilter 返回true时丢弃该请求
+	DoFilter(req *httplib.RequestCrawler) bool
+}
+
+// Config 创建过滤器的配置
+type Config struct {
+	HostLimit     string  // 限制爬取的域名
+	Store         string  // 去重存储的类型,为空时使用集合
+	FalsePositive float64 // 布隆过滤器的误判率
+	StatMaxKeys   int     // 智能过滤最多记录的统计项数量,为0时使用默认值
+}
+
+// NewStore 按照配置新建去重存储,配置在创建过滤器时已经校验过,这里出错时使用集合
+func (c Config) NewStore() UniqueStore {
+	store, err := NewUniqueStore(c.Store, c.FalsePositive)
+	if err != nil {
+		return NewSetStore()
+	}
+	return store
+}
+
+// Factory 过滤器的创建函数,每个设备的爬取都会创建新的过滤器
+type Factory func(config Config) Filter
+
+var (
+	factories    = map[string]Factory{}
+	factoryMutex sync.RWMutex
+)
+
+func init() {
+	_ = Register(ModeSimple, func(config Config) Filter {
+		return NewSimpleFilter(config)
+	})
+	_ = Register(ModeSmart, func(config Config) Filter {
+		return NewSmartFilter(config, false)
+	})
+	_ = Register(ModeStrict, func(config Config) Filter {
+		return NewSmartFilter(config, true)
+	})
+}
+
+// Register 按照名称注册过滤器,名称不区分大小写,不能重复注册
+func Register(name string, factory Factory) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || factory == nil {
+		return fmt.Errorf("filter name and factory are required")
+	}
+	factoryMutex.Lock()
+	defer factoryMutex.Unlock()
+	if _, ok := factories[name]; ok {
+		return fmt.Errorf("filter %s already registered", name)
+	}
+	factories[name] = factory
+	return nil
+}
+
+// New 按照名称创建过滤器,名称为空时使用智能模式
+func New(name string, config Config) (Filter, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = ModeSmart
+	}
+	factoryMutex.RLock()
+	factory, ok := factories[name]
+	factoryMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown filter mode %s, available: %s", name, strings.Join(Names(), ","))
+	}
+	if err := checkStore(config.Store, config.FalsePositive); err != nil {
+		return nil, err
+	}
+	return factory(config), nil
+}
+
+// Names 已经注册的过滤器名称
+func Names() []string {
+	factoryMutex.RLock()
+	defer factoryMutex.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSimpleFilter 新建普通模式过滤器
+func NewSimpleFilter(config Config) *SimpleFilter {
+	return &SimpleFilter{UniqueSet: config.NewStore(), HostLimit: config.HostLimit}
+}
+
+// NewSmartFilter 新建智能模式过滤器,strict为true时为严格模式
+func NewSmartFilter(config Config, strict bool) *SmartFilter {
+	s := &SmartFilter{
+		StrictMode:   strict,
+		SimpleFilter: *NewSimpleFilter(config),
+	}
+	s.Init()
+	s.uniqueMarkedIds = config.NewStore()
+	if config.StatMaxKeys > 0 {
+		s.statMaxKeys = config.StatMaxKeys
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"testing"
+)
+
+func newRequest(t *testing.T, rawURL string) *httplib.RequestCrawler {
+	u, err := urllib.GetURL(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httplib.GetCrawlerRequest("GET", u)
+}
+
+// countKept 依次过滤请求,返回保留的请求数量
+func countKept(t *testing.T, f Filter, urls ...string) int {
+	kept := 0
+	for _, u := range urls {
+		if !f.DoFilter(newRequest(t, u)) {
+			kept++
+		}
+	}
+	return kept
+}
+
+func TestNew_BuiltinModes(t *testing.T) {
+	urls := []string{
+		"https://example.com/list?code=Foo_Bar",
+		"https://example.com/list?code=Baz_Qux",
+		"https://example.com/list?code=Foo_Bar",
+		"https://other.com/list",
+		"https://example.com/logo.png",
+	}
+	for mode, expected := range map[string]int{ModeSimple: 2, ModeSmart: 2, ModeStrict: 1, "": 2, "STRICT": 1} {
+		f, err := New(mode, Config{HostLimit: "example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept := countKept(t, f, urls...); kept != expected {
+			t.Fatalf("mode %q: expected %d kept, got %d", mode, expected, kept)
+		}
+	}
+	if _, err := New("unknown", Config{HostLimit: "example.com"}); err == nil {
+		t.Fatal("expected error for unknown filter mode")
+	}
+}
+
+func TestNew_StrictModePlainValues(t *testing.T) {
+	urls := []string{
+		"https://example.com/list?page=home",
+		"https://example.com/list?page=about",
+		"https://example.com/list",
+	}
+	for _, mode := range []string{ModeSmart, ModeStrict} {
+		f, err := New(mode, Config{HostLimit: "example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept := countKept(t, f, urls...); kept != 3 {
+			t.Fatalf("mode %q: expected 3 kept, got %d", mode, kept)
+		}
+	}
+}
+
+type pathFilter struct {
+	hostLimit string
+	seen      map[string]bool
+}
+
+func (p *pathFilter) DoFilter(req *httplib.RequestCrawler) bool {
+	if req.URL.Host != p.hostLimit || p.seen[req.URL.Path] {
+		return true
+	}
+	p.seen[req.URL.Path] = true
+	return false
+}
+
+func TestRegister(t *testing.T) {
+	factory := func(config Config) Filter {
+		return &pathFilter{hostLimit: config.HostLimit, seen: map[string]bool{}}
+	}
+	if err := Register("Path", factory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		factoryMutex.Lock()
+		delete(factories, "path")
+		factoryMutex.Unlock()
+	})
+	if err := Register("path", factory); err == nil {
+		t.Fatal("expected error for duplicate filter name")
+	}
+	if err := Register(ModeSmart, factory); err == nil {
+		t.Fatal("builtin filter should not be replaced")
+	}
+	f, err := New("path", Config{HostLimit: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept := countKept(t, f, "https://example.com/a?x=1", "https://example.com/a?x=2", "https://example.com/b"); kept != 2 {
+		t.Fatalf("expected 2 kept, got %d", kept)
+	}
+	found := false
+	for _, name := range Names() {
+		found = found || name == "path"
+	}
+	if !found {
+		t.Fatalf("registered filter missing from %v", Names())
+	}
+}
@@ -0,0 +1,271 @@
+package filter
+
+import (
+	"bytes"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/html"
+	"hash/fnv"
+	"math/bits"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 这里按照渲染后页面的相似度折叠URL模板,同一个模板下出现多个几乎相同的页面后不再继续扩展,
+// 例如 /item?id=1 ... /item?id=500 或者把所有路径都渲染成同一个页面的兜底路由
+
+const (
+	// shingleSize 文本和DOM结构特征的窗口大小
+	shingleSize = 3
+	// maxTemplateClusters 每个模板最多记录的相似页面分组数量
+	maxTemplateClusters = 32
+)
+
+var (
+	// templateNumberRegex 路径中的数字
+	templateNumberRegex = regexp.MustCompile(`\d+`)
+	// ignoredTextTags 不参与文本特征的标签
+	ignoredTextTags = map[string]bool{"script": true, "style": true, "noscript": true, "template": true}
+)
+
+// PageFingerprint 渲染后页面的指纹,分别为可见文本和DOM结构的simhash
+type PageFingerprint struct {
+	Text uint64 `json:"text"`
+	DOM  uint64 `json:"dom"`
+}
+
+// Simhash 计算特征列表的64位simhash,相同的特征重复出现时权重累加
+func Simhash(features []string) uint64 {
+	if len(features) == 0 {
+		return 0
+	}
+	var weights [64]int
+	for _, feature := range features {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	var result uint64
+	for i, weight := range weights {
+		if weight > 0 {
+			result |= 1 << uint(i)
+		}
+	}
+	return result
+}
+
+// HammingDistance 两个simhash不同的位数
+func HammingDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Near 两个页面的文本和DOM结构的距离都不超过distance时视为几乎相同
+func (p PageFingerprint) Near(other PageFingerprint, distance int) bool {
+	return HammingDistance(p.Text, other.Text) <= distance && HammingDistance(p.DOM, other.DOM) <= distance
+}
+
+// FingerprintHTML 计算渲染后HTML的指纹,文本特征为连续的词,DOM特征为连续的标签名
+func FingerprintHTML(document string) PageFingerprint {
+	lexer := html.NewLexer(parse.NewInputString(document))
+	var words, tags []string
+	var ignored string
+	for {
+		tt, data := lexer.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		switch tt {
+		case html.StartTagToken:
+			name := strings.ToLower(string(lexer.Text()))
+			tags = append(tags, name)
+			if ignoredTextTags[name] {
+				ignored = name
+			}
+		case html.EndTagToken:
+			if strings.EqualFold(string(lexer.Text()), ignored) {
+				ignored = ""
+			}
+		case html.SvgToken, html.MathToken:
+			tags = append(tags, strings.ToLower(string(lexer.Text())))
+		case html.TextToken:
+			if ignored == "" {
+				words = append(words, textWords(data)...)
+			}
+		}
+	}
+	return PageFingerprint{Text: Simhash(shingles(words)), DOM: Simhash(shingles(tags))}
+}
+
+// textWords 文本中的词,字母和数字组成一个词,中日韩文字每个字作为一个词,
+// 数字统一替换为0,只有编号、价格和日期不同的页面文本特征相同
+func textWords(text []byte) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range string(bytes.ToLower(text)) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			words = append(words, string(r))
+		case unicode.IsDigit(r):
+			word = append(word, '0')
+		case unicode.IsLetter(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// shingles 将连续的shingleSize个元素作为一个特征,元素不足时整体作为一个特征
+func shingles(items []string) []string {
+	if len(items) == 0 {
+		return nil
+	}
+	if len(items) <= shingleSize {
+		return []string{strings.Join(items, " ")}
+	}
+	features := make([]string, 0, len(items)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(items); i++ {
+		features = append(features, strings.Join(items[i:i+shingleSize], " "))
+	}
+	return features
+}
+
+// URLTemplate 地址的模板,路径中的数字替换为 {n},查询参数只保留参数名,
+// 没有查询参数时最后一级路径视为变化的部分,替换为 *(保留后缀)
+func URLTemplate(u *urllib.URL) string {
+	p := templateNumberRegex.ReplaceAllString(u.Path, "{n}")
+	query := u.QueryMap()
+	if dir, file := path.Split(p); file != "" && len(query) == 0 {
+		p = dir + "*" + path.Ext(file)
+	}
+	if p == "" {
+		p = "/"
+	}
+	template := u.Host + p
+	if len(query) > 0 {
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		template += "?" + strings.Join(keys, "&")
+	}
+	return template
+}
+
+// NearDuplicateTemplate 因为页面几乎相同而停止扩展的地址模板
+type NearDuplicateTemplate struct {
+	Template string   `json:"template"` // 地址模板
+	Pages    int      `json:"pages"`    // 几乎相同的页面数量
+	Samples  []string `json:"samples"`  // 几乎相同的页面地址
+}
+
+// pageCluster 一个模板下几乎相同的一组页面
+type pageCluster struct {
+	fingerprint PageFingerprint
+	urls        []string
+}
+
+// templatePages 一个模板下已经访问的页面
+type templatePages struct {
+	clusters  []*pageCluster
+	saturated *pageCluster
+}
+
+// NearDuplicate 按照模板记录页面指纹,同一个模板下几乎相同的页面达到maxPages个后停止扩展该模板
+type NearDuplicate struct {
+	maxPages  int
+	distance  int
+	templates map[string]*templatePages
+	lock      sync.Mutex
+}
+
+// NewNearDuplicate 新建相似页面检测,distance为simhash的最大汉明距离
+func NewNearDuplicate(maxPages int, distance int) *NearDuplicate {
+	return &NearDuplicate{maxPages: maxPages, distance: distance, templates: map[string]*templatePages{}}
+}
+
+// Add 记录页面的指纹,模板因为这个页面达到阈值时返回true
+func (n *NearDuplicate) Add(u *urllib.URL, fingerprint PageFingerprint) bool {
+	template := URLTemplate(u)
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	pages, ok := n.templates[template]
+	if !ok {
+		pages = &templatePages{}
+		n.templates[template] = pages
+	}
+	if pages.saturated != nil {
+		return false
+	}
+	var cluster *pageCluster
+	for _, item := range pages.clusters {
+		if item.fingerprint.Near(fingerprint, n.distance) {
+			cluster = item
+			break
+		}
+	}
+	if cluster == nil {
+		if len(pages.clusters) >= maxTemplateClusters {
+			return false
+		}
+		cluster = &pageCluster{fingerprint: fingerprint}
+		pages.clusters = append(pages.clusters, cluster)
+	}
+	cluster.urls = append(cluster.urls, u.String())
+	if len(cluster.urls) < n.maxPages {
+		return false
+	}
+	pages.saturated = cluster
+	return true
+}
+
+// Saturated 地址所属的模板是否已经停止扩展
+func (n *NearDuplicate) Saturated(u *urllib.URL) bool {
+	template := URLTemplate(u)
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	pages, ok := n.templates[template]
+	return ok && pages.saturated != nil
+}
+
+// Templates 已经停止扩展的模板,按照模板排列
+func (n *NearDuplicate) Templates() []*NearDuplicateTemplate {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	var result []*NearDuplicateTemplate
+	for template, pages := range n.templates {
+		if pages.saturated == nil {
+			continue
+		}
+		result = append(result, &NearDuplicateTemplate{
+			Template: template,
+			Pages:    len(pages.saturated.urls),
+			Samples:  append([]string{}, pages.saturated.urls...),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Template < result[j].Template
+	})
+	return result
+}
@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"fmt"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"testing"
+)
+
+const itemPage = `<html><head><title>Item</title><script>var id = %d;</script></head><body>
+<div class="nav"><a href="/">Home</a><a href="/list">List</a><a href="/about">About</a></div>
+<div class="item"><h1>Product %d</h1><p>This product ships worldwide within three business days and comes with a one year warranty.</p>
+<p>Customers who viewed this product also viewed other products in the same category.</p><span>Price %d</span></div>
+<div class="footer"><p>Copyright example shop, all rights reserved.</p></div></body></html>`
+
+const articlePage = `<html><body><article><h1>Release notes</h1><ul><li>Added new export formats</li><li>Fixed login redirect</li></ul>
+<table><tr><td>Version</td><td>Date</td></tr><tr><td>2.0</td><td>2023</td></tr></table>
+<form action="/subscribe"><input name="email"><button>Subscribe to the newsletter</button></form></article></body></html>`
+
+func mustURL(t *testing.T, rawURL string) *urllib.URL {
+	u, err := urllib.GetURL(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestFingerprintHTML(t *testing.T) {
+	a := FingerprintHTML(fmt.Sprintf(itemPage, 1, 1, 10))
+	b := FingerprintHTML(fmt.Sprintf(itemPage, 2, 2, 20))
+	c := FingerprintHTML(articlePage)
+	if !a.Near(b, 6) {
+		t.Fatalf("item pages should be near: text %d dom %d", HammingDistance(a.Text, b.Text), HammingDistance(a.DOM, b.DOM))
+	}
+	if a.Near(c, 6) {
+		t.Fatal("different pages should not be near")
+	}
+	// 脚本中的内容不参与文本特征
+	if FingerprintHTML("<p>hello world</p><script>x = 1</script>").Text != FingerprintHTML("<p>hello world</p><script>y = 2</script>").Text {
+		t.Fatal("script text should be ignored")
+	}
+}
+
+func TestURLTemplate(t *testing.T) {
+	for rawURL, expected := range map[string]string{
+		"https://example.com/item?id=1&from=home": "example.com/item?from&id",
+		"https://example.com/post/hello-world":    "example.com/post/*",
+		"https://example.com/user/12/profile.php": "example.com/user/{n}/*.php",
+		"https://example.com/":                    "example.com/",
+	} {
+		if template := URLTemplate(mustURL(t, rawURL)); template != expected {
+			t.Fatalf("%s: expected %s, got %s", rawURL, expected, template)
+		}
+	}
+}
+
+func TestNearDuplicate(t *testing.T) {
+	n := NewNearDuplicate(3, 6)
+	article := FingerprintHTML(articlePage)
+	if n.Add(mustURL(t, "https://example.com/item?id=100"), article) {
+		t.Fatal("unexpected saturation")
+	}
+	for i := 1; i <= 3; i++ {
+		saturated := n.Add(mustURL(t, fmt.Sprintf("https://example.com/item?id=%d", i)), FingerprintHTML(fmt.Sprintf(itemPage, i, i, i*10)))
+		if saturated != (i == 3) {
+			t.Fatalf("page %d: unexpected saturation %v", i, saturated)
+		}
+	}
+	if !n.Saturated(mustURL(t, "https://example.com/item?id=999")) || n.Saturated(mustURL(t, "https://example.com/list?page=2")) {
+		t.Fatal("unexpected template state")
+	}
+	templates := n.Templates()
+	if len(templates) != 1 || templates[0].Template != "example.com/item?id" || templates[0].Pages != 3 {
+		t.Fatalf("unexpected templates %+v", templates)
+	}
+}
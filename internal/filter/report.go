@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"sort"
+	"sync"
+)
+
+// 请求被过滤的原因
+const (
+	ReasonDomain          = "domain"            // 不在限制的域名内
+	ReasonDuplicate       = "duplicate"         // 原始请求重复
+	ReasonStatic          = "static_suffix"     // 静态资源后缀
+	ReasonGraphQL         = "graphql_operation" // GraphQL操作重复
+	ReasonMarkedDuplicate = "marked_duplicate"  // 对参数值和路径打标记后重复
+	ReasonOverCount       = "over_count"        // 超过阈值的参数和路径修正标记后重复,修正规则见 MarkRules
+	ReasonCustom          = "custom"            // 自定义过滤器没有记录原因
+)
+
+// 超过阈值后修正标记的规则
+const (
+	RuleParamKeySingle  = "param_key_single"  // 某个URL的参数名组合重复超过 MaxParamKeySingleCount
+	RuleParamKeyAll     = "param_key_all"     // 所有URL中某个参数的不同值超过 MaxParamKeyAllCount
+	RulePathParamSymbol = "path_param_symbol" // 某个path下某个参数的标记次数超过 MaxPathParamKeySymbolCount
+	RulePathParamEmpty  = "path_param_empty"  // 某个path下空值参数名超过 MaxPathParamEmptyCount
+	RuleParentPath      = "parent_path"       // 上一级目录下的本级目录数量超过 MaxParentPathCount
+	RuleCustomLocation  = "custom_location"   // 参数位置曾经出现过Custom值,全局标记
+)
+
+// addMarkRule 记录对请求标记做了修正的规则
+func addMarkRule(req *httplib.RequestCrawler, rule string) {
+	for _, item := range req.Filter.MarkRules {
+		if item == rule {
+			return
+		}
+	}
+	req.Filter.MarkRules = append(req.Filter.MarkRules, rule)
+}
+
+// Decision 一个被过滤的请求,以及过滤时使用的标记
+type Decision struct {
+	Method         string                 `json:"method"`
+	URL            string                 `json:"url"`
+	Source         string                 `json:"source"`
+	Reason         string                 `json:"reason"`
+	MarkRules      []string               `json:"mark_rules,omitempty"`
+	MarkedPath     string                 `json:"marked_path,omitempty"`
+	MarkedQuery    map[string]interface{} `json:"marked_query,omitempty"`
+	MarkedPostData map[string]interface{} `json:"marked_post_data,omitempty"`
+	UniqueId       string                 `json:"unique_id,omitempty"`
+}
+
+// ReasonGroup 按照原因分组的被过滤请求,超过样本数量的请求只计数
+type ReasonGroup struct {
+	Reason    string      `json:"reason"`
+	Count     int         `json:"count"`
+	Decisions []*Decision `json:"decisions"`
+}
+
+// Report 过滤报告,记录被过滤的请求,用于调整过滤阈值
+type Report struct {
+	maxSamples int
+	groups     map[string]*ReasonGroup
+	lock       sync.Mutex
+}
+
+// NewReport 新建过滤报告,maxSamples为每个原因最多记录的请求数量
+func NewReport(maxSamples int) *Report {
+	return &Report{maxSamples: maxSamples, groups: map[string]*ReasonGroup{}}
+}
+
+// Add 记录一个被过滤的请求
+func (r *Report) Add(req *httplib.RequestCrawler) {
+	reason := req.Filter.Reason
+	if reason == "" {
+		reason = ReasonCustom
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	group, ok := r.groups[reason]
+	if !ok {
+		group = &ReasonGroup{Reason: reason}
+		r.groups[reason] = group
+	}
+	group.Count++
+	if len(group.Decisions) >= r.maxSamples {
+		return
+	}
+	group.Decisions = append(group.Decisions, &Decision{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		Source:         req.Source,
+		Reason:         reason,
+		MarkRules:      append([]string{}, req.Filter.MarkRules...),
+		MarkedPath:     req.Filter.MarkedPath,
+		MarkedQuery:    copyMarks(req.Filter.MarkedQueryMap),
+		MarkedPostData: copyMarks(req.Filter.MarkedPostDataMap),
+		UniqueId:       req.Filter.UniqueId,
+	})
+}
+
+// Groups 按照数量从多到少排列的分组,数量相同时按照原因排列
+func (r *Report) Groups() []*ReasonGroup {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	groups := make([]*ReasonGroup, 0, len(r.groups))
+	for _, group := range r.groups {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Reason < groups[j].Reason
+	})
+	return groups
+}
+
+func copyMarks(marks map[string]interface{}) map[string]interface{} {
+	if len(marks) == 0 {
+		return nil
+	}
+	result := make(map[string]interface{}, len(marks))
+	for key, value := range marks {
+		result[key] = value
+	}
+	return result
+}
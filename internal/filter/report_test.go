@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+// letters 将数字转换成只包含小写字母的路径,避免被标记为数字
+func letters(i int) string {
+	return string([]byte{'a' + byte(i/26), 'a' + byte(i%26)})
+}
+
+func TestReport(t *testing.T) {
+	f := NewSmartFilter(Config{HostLimit: "example.com"}, false)
+	report := NewReport(2)
+	urls := []string{
+		"https://other.com/",
+		"https://example.com/index",
+		"https://example.com/index",
+		"https://example.com/logo.png",
+		"https://example.com/list?code=ab12",
+		"https://example.com/list?code=cd34",
+	}
+	for i := 0; i < 40; i++ {
+		urls = append(urls, "https://example.com/blog/post"+letters(i))
+	}
+	for _, u := range urls {
+		req := newRequest(t, u)
+		if f.DoFilter(req) {
+			report.Add(req)
+		} else if req.Filter.Reason != "" {
+			t.Fatalf("kept request %s has reason %s", u, req.Filter.Reason)
+		}
+	}
+	counts := map[string]int{}
+	for _, group := range report.Groups() {
+		counts[group.Reason] = group.Count
+		if len(group.Decisions) > 2 {
+			t.Fatalf("samples not limited for %s", group.Reason)
+		}
+	}
+	expected := map[string]int{ReasonDomain: 1, ReasonDuplicate: 1, ReasonStatic: 1, ReasonMarkedDuplicate: 1, ReasonOverCount: 40 - 33}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("unexpected counts %v", counts)
+	}
+	for _, group := range report.Groups() {
+		decision := group.Decisions[0]
+		switch group.Reason {
+		case ReasonMarkedDuplicate:
+			if decision.MarkedPath != "/list" || decision.MarkedQuery["code"] == "cd34" {
+				t.Fatalf("unexpected marks %+v", decision)
+			}
+		case ReasonOverCount:
+			if !reflect.DeepEqual(decision.MarkRules, []string{RuleParentPath}) || decision.MarkedPath != "/blog/{{fix_path}}" {
+				t.Fatalf("unexpected marks %+v", decision)
+			}
+		}
+	}
+	// 自定义过滤器没有记录原因
+	report.Add(newRequest(t, "https://example.com/custom"))
+	groups := report.Groups()
+	if groups[0].Reason != ReasonOverCount || groups[1].Reason != ReasonCustom {
+		t.Fatalf("unexpected group order %s %s", groups[0].Reason, groups[1].Reason)
+	}
+}
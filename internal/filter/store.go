@@ -0,0 +1,239 @@
+package filter
+
+import (
+	"fmt"
+	mapset "github.com/deckarep/golang-set"
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// 去重存储的类型
+const (
+	StoreSet   = "set"   // 精确的集合,内存随元素数量线性增长
+	StoreBloom = "bloom" // 可扩展的布隆过滤器,存在误判,误判的请求会被当作重复请求过滤
+)
+
+const (
+	// bloomInitialCapacity 布隆过滤器第一层的容量
+	bloomInitialCapacity = 4096
+	// bloomGrowth 每一层容量的增长倍数
+	bloomGrowth = 2
+	// bloomTightening 每一层误判率的收紧比例,所有层的误判率之和不超过设置的误判率
+	bloomTightening = 0.5
+	// setItemOverhead 集合中每个元素除字符串内容以外的估算字节数
+	setItemOverhead = 64
+)
+
+// UniqueStore 去重存储,会被多个tab并发调用
+type UniqueStore interface {
+	// Add 元素不存在时添加并返回true,已经存在时返回false,布隆过滤器可能误判为已经存在
+	Add(key string) bool
+	// Contains 元素是否存在
+	Contains(key string) bool
+	// Len 添加过的元素数量
+	Len() int
+	// MemoryBytes 估算的内存占用字节数
+	MemoryBytes() int
+	// Type 存储的类型
+	Type() string
+}
+
+// NewUniqueStore 按照类型新建去重存储,类型为空时使用集合,falsePositive为布隆过滤器的误判率
+func NewUniqueStore(store string, falsePositive float64) (UniqueStore, error) {
+	if err := checkStore(store, falsePositive); err != nil {
+		return nil, err
+	}
+	if store == StoreBloom {
+		return NewBloomStore(falsePositive), nil
+	}
+	return NewSetStore(), nil
+}
+
+// checkStore 校验去重存储的类型和误判率
+func checkStore(store string, falsePositive float64) error {
+	switch store {
+	case "", StoreSet:
+		return nil
+	case StoreBloom:
+		if falsePositive <= 0 || falsePositive >= 1 {
+			return fmt.Errorf("invalid bloom filter false positive rate %v", falsePositive)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown filter store %s, available: %s,%s", store, StoreSet, StoreBloom)
+}
+
+// SetStore 基于集合的精确去重
+type SetStore struct {
+	set   mapset.Set
+	bytes int
+	lock  sync.Mutex
+}
+
+// NewSetStore 新建集合去重存储
+func NewSetStore() *SetStore {
+	return &SetStore{set: mapset.NewThreadUnsafeSet()}
+}
+
+func (s *SetStore) Add(key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.set.Add(key) {
+		return false
+	}
+	s.bytes += len(key) + setItemOverhead
+	return true
+}
+
+func (s *SetStore) Contains(key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.set.Contains(key)
+}
+
+func (s *SetStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.set.Cardinality()
+}
+
+func (s *SetStore) MemoryBytes() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.bytes
+}
+
+func (s *SetStore) Type() string {
+	return StoreSet
+}
+
+// BloomStore 可扩展的布隆过滤器,当前层写满后新建一层容量更大、误判率更低的过滤器,
+// 内存只与元素数量和误判率有关,与元素的长度无关
+type BloomStore struct {
+	layers        []*bloomLayer
+	falsePositive float64
+	count         int
+	lock          sync.Mutex
+}
+
+// bloomLayer 布隆过滤器的一层
+type bloomLayer struct {
+	bits     []uint64
+	m        uint64 // 位数
+	k        uint64 // 哈希函数数量
+	capacity int
+	count    int
+}
+
+// NewBloomStore 新建布隆过滤器去重存储
+func NewBloomStore(falsePositive float64) *BloomStore {
+	b := &BloomStore{falsePositive: falsePositive}
+	b.layers = append(b.layers, newBloomLayer(bloomInitialCapacity, falsePositive*(1-bloomTightening)))
+	return b
+}
+
+func newBloomLayer(capacity int, falsePositive float64) *bloomLayer {
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(falsePositive) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomLayer{bits: make([]uint64, (m+63)/64), m: m, k: k, capacity: capacity}
+}
+
+// bloomSeeds 双重哈希使用的两个种子,布隆过滤器只在进程内使用,不需要固定的哈希值
+var bloomSeeds = [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()}
+
+// bloomHash 计算双重哈希的两个哈希值,第二个哈希值取奇数,避免为0时k个位置全部相同
+func bloomHash(key string) (uint64, uint64) {
+	return maphash.String(bloomSeeds[0], key), maphash.String(bloomSeeds[1], key) | 1
+}
+
+func (l *bloomLayer) contains(h1 uint64, h2 uint64) bool {
+	for i := uint64(0); i < l.k; i++ {
+		pos := (h1 + i*h2) % l.m
+		if l.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *bloomLayer) add(h1 uint64, h2 uint64) {
+	for i := uint64(0); i < l.k; i++ {
+		pos := (h1 + i*h2) % l.m
+		l.bits[pos/64] |= 1 << (pos % 64)
+	}
+	l.count++
+}
+
+func (b *BloomStore) contains(h1 uint64, h2 uint64) bool {
+	for _, layer := range b.layers {
+		if layer.contains(h1, h2) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BloomStore) Add(key string) bool {
+	h1, h2 := bloomHash(key)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.contains(h1, h2) {
+		return false
+	}
+	last := b.layers[len(b.layers)-1]
+	if last.count >= last.capacity {
+		rate := b.falsePositive * (1 - bloomTightening) * math.Pow(bloomTightening, float64(len(b.layers)))
+		last = newBloomLayer(last.capacity*bloomGrowth, rate)
+		b.layers = append(b.layers, last)
+	}
+	last.add(h1, h2)
+	b.count++
+	return true
+}
+
+func (b *BloomStore) Contains(key string) bool {
+	h1, h2 := bloomHash(key)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.contains(h1, h2)
+}
+
+func (b *BloomStore) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.count
+}
+
+func (b *BloomStore) MemoryBytes() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	bytes := 0
+	for _, layer := range b.layers {
+		bytes += len(layer.bits) * 8
+	}
+	return bytes
+}
+
+func (b *BloomStore) Type() string {
+	return StoreBloom
+}
+
+// MemoryUsage 过滤器估算的内存占用
+type MemoryUsage struct {
+	Device      string `json:"device,omitempty"` // 爬取使用的设备配置
+	Store       string `json:"store"`            // 去重存储的类型
+	UniqueItems int    `json:"unique_items"`     // 去重存储中的元素数量
+	UniqueBytes int    `json:"unique_bytes"`     // 去重存储的字节数
+	StatKeys    int    `json:"stat_keys"`        // 智能过滤的统计项数量
+	StatValues  int    `json:"stat_values"`      // 统计项中记录的值的数量
+	StatBytes   int    `json:"stat_bytes"`       // 统计项的字节数
+}
+
+// MemoryReporter 能够报告内存占用的过滤器
+type MemoryReporter interface {
+	MemoryUsage() MemoryUsage
+}
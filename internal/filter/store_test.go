@@ -0,0 +1,82 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomStore(t *testing.T) {
+	store, err := NewUniqueStore(StoreBloom, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 超过第一层的容量,检查扩展之后已添加的元素仍然存在
+	total := bloomInitialCapacity * 5
+	added := 0
+	for i := 0; i < total; i++ {
+		if store.Add(fmt.Sprintf("https://example.com/item?id=%d", i)) {
+			added++
+		}
+	}
+	for i := 0; i < total; i++ {
+		if !store.Contains(fmt.Sprintf("https://example.com/item?id=%d", i)) {
+			t.Fatalf("missing item %d", i)
+		}
+		if store.Add(fmt.Sprintf("https://example.com/item?id=%d", i)) {
+			t.Fatalf("item %d added twice", i)
+		}
+	}
+	if store.Len() != added || total-added > total/1000 {
+		t.Fatalf("unexpected count %d of %d", store.Len(), total)
+	}
+	falsePositive := 0
+	for i := 0; i < total; i++ {
+		if store.Contains(fmt.Sprintf("https://example.com/other?id=%d", i)) {
+			falsePositive++
+		}
+	}
+	if float64(falsePositive)/float64(total) > 0.002 {
+		t.Fatalf("false positive rate too high: %d of %d", falsePositive, total)
+	}
+	set := NewSetStore()
+	for i := 0; i < total; i++ {
+		set.Add(fmt.Sprintf("https://example.com/item?id=%d", i))
+	}
+	if store.MemoryBytes() >= set.MemoryBytes() {
+		t.Fatalf("bloom store should use less memory: %d >= %d", store.MemoryBytes(), set.MemoryBytes())
+	}
+}
+
+func TestNewUniqueStore_Invalid(t *testing.T) {
+	for _, item := range []struct {
+		store string
+		rate  float64
+	}{{StoreBloom, 0}, {StoreBloom, 1}, {"cuckoo", 0.01}} {
+		if _, err := NewUniqueStore(item.store, item.rate); err == nil {
+			t.Fatalf("expected error for %s %v", item.store, item.rate)
+		}
+	}
+	if _, err := New(ModeSmart, Config{Store: "cuckoo"}); err == nil {
+		t.Fatal("expected error for unknown store")
+	}
+}
+
+func TestSmartFilter_StatMaxKeys(t *testing.T) {
+	f := NewSmartFilter(Config{HostLimit: "example.com", Store: StoreBloom, FalsePositive: 0.001, StatMaxKeys: 20}, false)
+	for i := 0; i < 200; i++ {
+		f.DoFilter(newRequest(t, fmt.Sprintf("https://example.com/dir%d/page?k%d=v&name=a%d", i, i, i)))
+	}
+	usage := f.MemoryUsage()
+	if usage.StatKeys != 20 || usage.Store != StoreBloom || usage.UniqueItems == 0 || usage.StatBytes == 0 {
+		t.Fatalf("unexpected memory usage %+v", usage)
+	}
+	// 统计集合的元素数量不超过阈值加一
+	f.filterParamKeyAllValues.Range(func(key, value interface{}) bool {
+		if key == "name" {
+			if n := value.(interface{ Cardinality() int }).Cardinality(); n > 11 {
+				t.Fatalf("stat set not bounded: %d", n)
+			}
+		}
+		return true
+	})
+}
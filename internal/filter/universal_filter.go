@@ -5,6 +5,7 @@ import (
 	"github.com/sairson/crawlergo/internal/engine/enums"
 	"github.com/sairson/crawlergo/internal/engine/httplib"
 	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"github.com/sairson/crawlergo/internal/graphql"
 	"github.com/sairson/crawlergo/internal/option"
 	"github.com/sairson/crawlergo/pkg/utils"
 	"go/types"
@@ -12,6 +13,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // SmartFilter 智能过滤器
@@ -25,14 +27,20 @@ type SmartFilter struct {
 	filterParamKeyAllValues    sync.Map
 	filterPathParamEmptyValues sync.Map
 	filterParentPathValues     sync.Map
-	uniqueMarkedIds            mapset.Set // 标记后的唯一ID，用于去重
+	uniqueMarkedIds            UniqueStore // 标记后的唯一ID，用于去重
+	statMaxKeys                int         // 统计项数量上限,达到后不再新建统计项
+	statKeys                   int64       // 统计项数量
+	statValues                 int64       // 统计集合中值的数量
 }
 
 type SimpleFilter struct {
-	UniqueSet mapset.Set
+	UniqueSet UniqueStore
 	HostLimit string
 }
 
+// statKeyOverhead 每个统计项估算的字节数,包括 sync.Map 的条目、键和统计集合
+const statKeyOverhead = 256
+
 var (
 	staticSuffixSet = option.StaticSuffixSet.Clone()
 )
@@ -45,24 +53,33 @@ func (s *SmartFilter) Init() {
 	s.filterParamKeyAllValues = sync.Map{}
 	s.filterPathParamEmptyValues = sync.Map{}
 	s.filterParentPathValues = sync.Map{}
-	s.uniqueMarkedIds = mapset.NewSet()
+	s.uniqueMarkedIds = NewSetStore()
+	s.statMaxKeys = enums.FilterStatMaxKeys
+	s.statKeys = 0
+	s.statValues = 0
 }
 
 // DoFilter 做普通模式的过滤操作
 func (s *SimpleFilter) DoFilter(req *httplib.RequestCrawler) bool {
 	if s.UniqueSet == nil {
-		s.UniqueSet = mapset.NewSet()
+		s.UniqueSet = NewSetStore()
 	}
+	// 同一个请求在每个设备下都会重新过滤,清除上一次的结果
+	req.Filter.Reason = ""
+	req.Filter.MarkRules = nil
 	// 首先判断是否需要过滤域名
 	if s.HostLimit != "" && s.DomainFilter(req) {
+		req.Filter.Reason = ReasonDomain
 		return true
 	}
 	// 去重过滤
 	if s.UniqueFilter(req) {
+		req.Filter.Reason = ReasonDuplicate
 		return true
 	}
 	// 过滤静态资源
 	if s.StaticFilter(req) {
+		req.Filter.Reason = ReasonStatic
 		return true
 	}
 	return false
@@ -71,20 +88,23 @@ func (s *SimpleFilter) DoFilter(req *httplib.RequestCrawler) bool {
 // UniqueFilter 请求去重
 func (s *SimpleFilter) UniqueFilter(req *httplib.RequestCrawler) bool {
 	if s.UniqueSet == nil {
-		s.UniqueSet = mapset.NewSet()
+		s.UniqueSet = NewSetStore()
 	}
-	if s.UniqueSet.Contains(req.UniqueId()) {
-		return true
-	} else {
-		s.UniqueSet.Add(req.UniqueId())
-		return false
+	return !s.UniqueSet.Add(req.UniqueId())
+}
+
+// MemoryUsage 估算的内存占用
+func (s *SimpleFilter) MemoryUsage() MemoryUsage {
+	if s.UniqueSet == nil {
+		return MemoryUsage{Store: StoreSet}
 	}
+	return MemoryUsage{Store: s.UniqueSet.Type(), UniqueItems: s.UniqueSet.Len(), UniqueBytes: s.UniqueSet.MemoryBytes()}
 }
 
 // DomainFilter 域名过滤
 func (s *SimpleFilter) DomainFilter(req *httplib.RequestCrawler) bool {
 	if s.UniqueSet == nil {
-		s.UniqueSet = mapset.NewSet()
+		s.UniqueSet = NewSetStore()
 	}
 	if req.URL.Host == s.HostLimit || req.URL.Hostname() == s.HostLimit {
 		return false
@@ -105,7 +125,7 @@ func (s *SimpleFilter) DomainFilter(req *httplib.RequestCrawler) bool {
 // StaticFilter 静态资源过滤
 func (s *SimpleFilter) StaticFilter(req *httplib.RequestCrawler) bool {
 	if s.UniqueSet == nil {
-		s.UniqueSet = mapset.NewSet()
+		s.UniqueSet = NewSetStore()
 	}
 	// 首先将slice转换成map
 	if req.URL.FileExt() == "" {
@@ -129,9 +149,14 @@ func (s *SmartFilter) DoFilter(req *httplib.RequestCrawler) bool {
 	}
 	req.Filter.FragmentID = s.CalcFragmentID(req.URL.Fragment)
 
+	// GraphQL请求都发送到同一个地址,请求体的结构也相同,按照操作去重
+	if operations, ok := graphql.ParseRequest(req); ok {
+		return s.graphqlFilter(req, operations)
+	}
+
 	// 标记
 	if req.Method == enums.GET || req.Method == enums.DELETE || req.Method == enums.HEAD || req.Method == enums.OPTIONS {
-		s.GetMark(*req)
+		s.GetMark(req)
 		s.repeatCountStatistic(req)
 	} else if req.Method == enums.POST || req.Method == enums.PUT {
 		s.postMark(req)
@@ -141,6 +166,7 @@ func (s *SmartFilter) DoFilter(req *httplib.RequestCrawler) bool {
 	// 对标记后的请求进行去重
 	uniqueId := req.Filter.UniqueId
 	if s.uniqueMarkedIds.Contains(uniqueId) {
+		req.Filter.Reason = ReasonMarkedDuplicate
 		return true
 	}
 
@@ -167,6 +193,7 @@ func (s *SmartFilter) DoFilter(req *httplib.RequestCrawler) bool {
 	// 新的ID再次去重
 	newUniqueId := req.Filter.UniqueId
 	if s.uniqueMarkedIds.Contains(newUniqueId) {
+		req.Filter.Reason = ReasonOverCount
 		return true
 	}
 
@@ -175,6 +202,22 @@ func (s *SmartFilter) DoFilter(req *httplib.RequestCrawler) bool {
 	return false
 }
 
+// graphqlFilter 按照请求中的操作对GraphQL请求去重
+func (s *SmartFilter) graphqlFilter(req *httplib.RequestCrawler, operations []*graphql.Operation) bool {
+	var keys []string
+	for _, op := range operations {
+		keys = append(keys, op.Key())
+	}
+	sort.Strings(keys)
+	req.Filter.UniqueId = utils.CalcMD5Hash(req.Method + strings.Join(keys, ","))
+	if s.uniqueMarkedIds.Contains(req.Filter.UniqueId) {
+		req.Filter.Reason = ReasonGraphQL
+		return true
+	}
+	s.uniqueMarkedIds.Add(req.Filter.UniqueId)
+	return false
+}
+
 func (s *SmartFilter) CalcFragmentID(fragment string) string {
 	if fragment == "" || !strings.HasPrefix(fragment, "/") {
 		return ""
@@ -184,19 +227,19 @@ func (s *SmartFilter) CalcFragmentID(fragment string) string {
 		return ""
 	}
 	fakeRequest := httplib.GetCrawlerRequest(enums.GET, fakeUrl)
-	s.GetMark(*fakeRequest)
+	s.GetMark(fakeRequest)
 	return fakeRequest.Filter.UniqueId
 }
 
-// GetMark 为请求打标记
-func (s *SmartFilter) GetMark(req httplib.RequestCrawler) {
+// GetMark 为请求打标记,标记结果写入请求的Filter中
+func (s *SmartFilter) GetMark(req *httplib.RequestCrawler) {
 	// 解码前的预先替换
 	todoUrl := *(req.URL)
 	todoUrl.RawQuery = s.preQueryMark(todoUrl.RawQuery)
 	// 依次打标记
 	queryMap := todoUrl.QueryMap()
 	queryMap = s.markParamName(queryMap)
-	queryMap = s.markParamValue(queryMap, &req)
+	queryMap = s.markParamValue(queryMap, req)
 	markedPath := s.MarkPath(todoUrl.Path)
 	// 计算唯一的ID
 	var queryKeyID string
@@ -217,7 +260,7 @@ func (s *SmartFilter) GetMark(req httplib.RequestCrawler) {
 	req.Filter.PathId = pathID
 
 	// 最后计算标记后的唯一请求ID
-	req.Filter.UniqueId = s.getMarkedUniqueID(&req)
+	req.Filter.UniqueId = s.getMarkedUniqueID(req)
 }
 
 // preQueryMark Query的Map对象会自动解码，所以对RawQuery进行预先的标记
@@ -326,6 +369,8 @@ func (s *SmartFilter) markParamValue(paramMap map[string]interface{}, req *httpl
 				}
 				if count >= 3 {
 					markedParamMap[key] = enums.MixStringMark
+				} else {
+					markedParamMap[key] = value
 				}
 			}
 		} else {
@@ -442,56 +487,33 @@ func (s *SmartFilter) repeatCountStatistic(req *httplib.RequestCrawler) {
 	if queryKeyId != "" {
 		// 所有参数名重复数量统计
 		if v, ok := s.filterParamKeyRepeatCount.Load(queryKeyId); ok {
-			s.filterParamKeyRepeatCount.Store(queryKeyId, v.(int)+1)
+			s.storeStatCount(&s.filterParamKeyRepeatCount, queryKeyId, v.(int)+1)
 		} else {
-			s.filterParamKeyRepeatCount.Store(queryKeyId, 1)
+			s.storeStatCount(&s.filterParamKeyRepeatCount, queryKeyId, 1)
 		}
 
 		for key, value := range req.Filter.MarkedQueryMap {
 			// 某个URL的所有参数名重复数量统计
 			paramQueryKey := queryKeyId + key
 
-			if set, ok := s.filterParamKeySingleValues.Load(paramQueryKey); ok {
-				set := set.(mapset.Set)
-				set.Add(value)
-			} else {
-				s.filterParamKeySingleValues.Store(paramQueryKey, mapset.NewSet(value))
-			}
+			s.addStatValue(&s.filterParamKeySingleValues, paramQueryKey, value, 3)
 
 			//本轮所有URL中某个参数重复数量统计
-			if _, ok := s.filterParamKeyAllValues.Load(key); !ok {
-				s.filterParamKeyAllValues.Store(key, mapset.NewSet(value))
-			} else {
-				if v, ok := s.filterParamKeyAllValues.Load(key); ok {
-					set := v.(mapset.Set)
-					if !set.Contains(value) {
-						set.Add(value)
-					}
-				}
-			}
+			s.addStatValue(&s.filterParamKeyAllValues, key, value, enums.MaxParamKeyAllCount)
 
 			// 如果参数值为空，统计该PATH下的空值参数名个数
 			if value == "" {
-				if _, ok := s.filterPathParamEmptyValues.Load(pathId); !ok {
-					s.filterPathParamEmptyValues.Store(pathId, mapset.NewSet(key))
-				} else {
-					if v, ok := s.filterPathParamEmptyValues.Load(pathId); ok {
-						set := v.(mapset.Set)
-						if !set.Contains(key) {
-							set.Add(key)
-						}
-					}
-				}
+				s.addStatValue(&s.filterPathParamEmptyValues, pathId, key, enums.MaxPathParamEmptyCount)
 			}
 
 			pathIdKey := pathId + key
 			// 某path下的参数值去重标记出现次数统计
 			if v, ok := s.filterPathParamKeySymbol.Load(pathIdKey); ok {
 				if enums.MarkedStringRegex.MatchString(value.(string)) {
-					s.filterPathParamKeySymbol.Store(pathIdKey, v.(int)+1)
+					s.storeStatCount(&s.filterPathParamKeySymbol, pathIdKey, v.(int)+1)
 				}
 			} else {
-				s.filterPathParamKeySymbol.Store(pathIdKey, 1)
+				s.storeStatCount(&s.filterPathParamKeySymbol, pathIdKey, 1)
 			}
 
 		}
@@ -504,16 +526,60 @@ func (s *SmartFilter) repeatCountStatistic(req *httplib.RequestCrawler) {
 
 	parentPathId := utils.CalcMD5Hash(req.URL.ParentPath())
 	currentPath := strings.Replace(req.Filter.MarkedPath, req.URL.ParentPath(), "", -1)
-	if _, ok := s.filterParentPathValues.Load(parentPathId); !ok {
-		s.filterParentPathValues.Store(parentPathId, mapset.NewSet(currentPath))
-	} else {
-		if v, ok := s.filterParentPathValues.Load(parentPathId); ok {
-			set := v.(mapset.Set)
-			if !set.Contains(currentPath) {
-				set.Add(currentPath)
-			}
+	s.addStatValue(&s.filterParentPathValues, parentPathId, currentPath, enums.MaxParentPathCount)
+}
+
+// newStatKey 申请新建一个统计项,统计项数量达到上限后返回false,之后只更新已有的统计项
+func (s *SmartFilter) newStatKey() bool {
+	if atomic.AddInt64(&s.statKeys, 1) > int64(s.statMaxKeys) {
+		atomic.AddInt64(&s.statKeys, -1)
+		return false
+	}
+	return true
+}
+
+// addStatValue 向统计集合中添加值,集合只用于和阈值比较,元素数量超过limit后不再添加
+func (s *SmartFilter) addStatValue(m *sync.Map, key string, value interface{}, limit int) {
+	v, ok := m.Load(key)
+	if !ok {
+		if !s.newStatKey() {
+			return
+		}
+		var loaded bool
+		if v, loaded = m.LoadOrStore(key, mapset.NewSet()); loaded {
+			atomic.AddInt64(&s.statKeys, -1)
 		}
 	}
+	set := v.(mapset.Set)
+	if set.Cardinality() <= limit && set.Add(value) {
+		atomic.AddInt64(&s.statValues, 1)
+	}
+}
+
+// storeStatCount 更新统计计数,统计项数量达到上限后不再新建统计项
+func (s *SmartFilter) storeStatCount(m *sync.Map, key string, count int) {
+	if _, ok := m.Load(key); !ok {
+		if !s.newStatKey() {
+			return
+		}
+		if _, loaded := m.LoadOrStore(key, count); !loaded {
+			return
+		}
+		atomic.AddInt64(&s.statKeys, -1)
+	}
+	m.Store(key, count)
+}
+
+// MemoryUsage 估算的内存占用,包括两个去重存储和统计项
+func (s *SmartFilter) MemoryUsage() MemoryUsage {
+	usage := s.SimpleFilter.MemoryUsage()
+	usage.Store = s.uniqueMarkedIds.Type()
+	usage.UniqueItems += s.uniqueMarkedIds.Len()
+	usage.UniqueBytes += s.uniqueMarkedIds.MemoryBytes()
+	usage.StatKeys = int(atomic.LoadInt64(&s.statKeys))
+	usage.StatValues = int(atomic.LoadInt64(&s.statValues))
+	usage.StatBytes = usage.StatKeys*statKeyOverhead + usage.StatValues*setItemOverhead
+	return usage
 }
 
 func (s *SmartFilter) inCommonScriptSuffix(suffix string) bool {
@@ -552,6 +618,7 @@ func (s *SmartFilter) globalFilterLocationMark(req *httplib.RequestCrawler) {
 			name += key
 			if s.filterLocationSet.Contains(name) {
 				req.Filter.MarkedQueryMap[key] = enums.CustomValueMark
+				addMarkRule(req, RuleCustomLocation)
 			}
 		}
 	} else if req.Method == enums.POST || req.Method == enums.PUT {
@@ -559,6 +626,7 @@ func (s *SmartFilter) globalFilterLocationMark(req *httplib.RequestCrawler) {
 			name += key
 			if s.filterLocationSet.Contains(name) {
 				req.Filter.MarkedPostDataMap[key] = enums.CustomValueMark
+				addMarkRule(req, RuleCustomLocation)
 			}
 		}
 	}
@@ -577,6 +645,7 @@ func (s *SmartFilter) overCountMark(req *httplib.RequestCrawler) {
 					set := set.(mapset.Set)
 					if set.Cardinality() > 3 {
 						req.Filter.MarkedQueryMap[key] = enums.FixParamRepeatMark
+						addMarkRule(req, RuleParamKeySingle)
 					}
 				}
 			}
@@ -588,6 +657,7 @@ func (s *SmartFilter) overCountMark(req *httplib.RequestCrawler) {
 				paramKeySet := paramKeySet.(mapset.Set)
 				if paramKeySet.Cardinality() > enums.MaxParamKeyAllCount {
 					req.Filter.MarkedQueryMap[key] = enums.FixParamRepeatMark
+					addMarkRule(req, RuleParamKeyAll)
 				}
 			}
 
@@ -595,6 +665,7 @@ func (s *SmartFilter) overCountMark(req *httplib.RequestCrawler) {
 			// 某个PATH的GET参数值去重标记出现次数超过阈值，则对该PATH的该参数进行全局标记
 			if v, ok := s.filterPathParamKeySymbol.Load(pathIdKey); ok && v.(int) > enums.MaxPathParamKeySymbolCount {
 				req.Filter.MarkedQueryMap[key] = enums.FixParamRepeatMark
+				addMarkRule(req, RulePathParamSymbol)
 			}
 		}
 
@@ -606,6 +677,7 @@ func (s *SmartFilter) overCountMark(req *httplib.RequestCrawler) {
 				for key, value := range req.Filter.MarkedQueryMap {
 					if value == "" {
 						newMarkerQueryMap[enums.FixParamRepeatMark] = ""
+						addMarkRule(req, RulePathParamEmpty)
 					} else {
 						newMarkerQueryMap[key] = value
 					}
@@ -623,6 +695,7 @@ func (s *SmartFilter) overCountMark(req *httplib.RequestCrawler) {
 	if set, ok := s.filterParentPathValues.Load(parentPathId); ok {
 		set := set.(mapset.Set)
 		if set.Cardinality() > enums.MaxParentPathCount {
+			addMarkRule(req, RuleParentPath)
 			if strings.HasSuffix(req.URL.ParentPath(), "/") {
 				req.Filter.MarkedPath = req.URL.ParentPath() + enums.FixPathMark
 			} else {
@@ -0,0 +1,271 @@
+package graphql
+
+import (
+	"encoding/json"
+	"github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/pkg/utils"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GraphQL的请求都发送到同一个地址,这里从请求体和脚本中解析出具体的操作,按照操作输出结果和去重
+
+// 操作的来源
+const (
+	FromRequest       = "Request"       // 浏览器中捕获的请求
+	FromJavaScript    = "JavaScript"    // 脚本中的查询语句
+	FromIntrospection = "Introspection" // 内省查询得到的schema
+)
+
+// Operation 一个GraphQL操作
+type Operation struct {
+	Endpoint  string            `json:"endpoint"`  // 请求地址,从脚本中解析时为空
+	Method    string            `json:"method"`    // 请求方法
+	Name      string            `json:"name"`      // 操作名称,匿名操作为空
+	Type      string            `json:"type"`      // query、mutation、subscription,只有持久化查询时为unknown
+	Variables map[string]string `json:"variables"` // 变量名称和类型
+	Query     string            `json:"query"`     // 查询语句
+	Source    string            `json:"source"`    // 操作的来源
+}
+
+var (
+	// variableRegex 去掉空白后的变量定义 $id:ID!
+	variableRegex = regexp.MustCompile(`\$([_A-Za-z][_0-9A-Za-z]*):([\[\]!_0-9A-Za-z]+)`)
+	// documentRegex 脚本中的查询语句,gql模板字符串或普通字符串
+	documentRegex = regexp.MustCompile("(?s)(?:gql|graphql)\\s*(?:\\(\\s*)?`([^`]+)`|[\"'`]\\s*((?:query|mutation|subscription)\\s+[_A-Za-z][_0-9A-Za-z]*\\s*[({][^\"'`]*)[\"'`]")
+	// endpointRegex 脚本中的GraphQL地址
+	endpointRegex = regexp.MustCompile(`["'\x60]((?:https?:)?(?://[^"'\x60/\s]+)?/[\w\-./]*graphql[\w\-./]*)["'\x60]`)
+	// interpolationRegex 模板字符串中的片段插值 ${Fragment}
+	interpolationRegex = regexp.MustCompile(`\$\{[^}]*\}`)
+)
+
+// Key 操作的唯一标识,用于按照操作去重,匿名操作使用查询语句的hash
+func (op *Operation) Key() string {
+	name := op.Name
+	if name == "" {
+		name = utils.CalcMD5Hash(strings.Join(strings.Fields(op.Query), " "))
+	}
+	return op.Endpoint + "|" + op.Type + "|" + name
+}
+
+// requestBody GraphQL请求体
+type requestBody struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName"`
+	Extensions    struct {
+		PersistedQuery json.RawMessage `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// ParseRequest 判断请求是否为GraphQL请求,并解析请求中的操作
+func ParseRequest(req *httplib.RequestCrawler) ([]*Operation, bool) {
+	var bodies []requestBody
+	if req.Method == enums.GET {
+		query := req.URL.Query()
+		body := requestBody{Query: query.Get("query"), OperationName: query.Get("operationName")}
+		if extensions := query.Get("extensions"); extensions != "" {
+			_ = json.Unmarshal([]byte(extensions), &body.Extensions)
+		}
+		bodies = append(bodies, body)
+	} else if contentType := strings.ToLower(headerValue(req.Headers, "Content-Type")); strings.HasPrefix(contentType, "application/graphql") {
+		bodies = append(bodies, requestBody{Query: req.PostData})
+	} else {
+		data := strings.TrimSpace(req.PostData)
+		if strings.HasPrefix(data, "[") {
+			// 批量查询
+			_ = json.Unmarshal([]byte(data), &bodies)
+		} else if strings.HasPrefix(data, "{") {
+			var body requestBody
+			if json.Unmarshal([]byte(data), &body) == nil {
+				bodies = append(bodies, body)
+			}
+		} else if values, err := url.ParseQuery(data); err == nil && values.Get("query") != "" {
+			bodies = append(bodies, requestBody{Query: values.Get("query"), OperationName: values.Get("operationName")})
+		}
+	}
+	endpoint := req.URL.NoQueryUrl()
+	var operations []*Operation
+	for _, body := range bodies {
+		if body.Query == "" {
+			// 持久化查询只有操作名称
+			if len(body.Extensions.PersistedQuery) > 0 && body.OperationName != "" {
+				operations = append(operations, &Operation{Name: body.OperationName, Type: "unknown", Variables: map[string]string{}})
+			}
+			continue
+		}
+		ops := ParseDocument(body.Query)
+		if body.OperationName != "" {
+			for _, op := range ops {
+				if op.Name == body.OperationName {
+					ops = []*Operation{op}
+					break
+				}
+			}
+		}
+		operations = append(operations, ops...)
+	}
+	for _, op := range operations {
+		op.Endpoint = endpoint
+		op.Method = req.Method
+		op.Source = FromRequest
+	}
+	return operations, len(operations) > 0
+}
+
+// ParseDocument 解析查询语句中的全部操作,只处理最外层的定义,片段定义会被忽略
+func ParseDocument(document string) []*Operation {
+	var operations []*Operation
+	depth := 0
+	pending := false // 已经读取到定义的头部,下一个 { 属于该定义
+	for i := 0; i < len(document); i++ {
+		c := document[i]
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			i = skipString(document, i)
+		case c == '{':
+			if depth == 0 && !pending {
+				// 简写的匿名查询
+				operations = append(operations, &Operation{Type: "query", Variables: map[string]string{}, Query: document})
+			}
+			pending = false
+			depth++
+		case c == '}':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && !pending && isNameStart(c):
+			start := i
+			for i < len(document) && isNameChar(document[i]) {
+				i++
+			}
+			word := document[start:i]
+			switch word {
+			case "query", "mutation", "subscription":
+				op, end := parseDefinitionHeader(document, i)
+				op.Type = word
+				op.Query = document
+				operations = append(operations, op)
+				i = end
+				pending = true
+			case "fragment":
+				pending = true
+			}
+			i--
+		}
+	}
+	return operations
+}
+
+// parseDefinitionHeader 解析操作定义的名称和变量,返回定义头部结束的位置
+func parseDefinitionHeader(document string, i int) (*Operation, int) {
+	op := &Operation{Variables: map[string]string{}}
+	for i < len(document) && isSpace(document[i]) {
+		i++
+	}
+	start := i
+	for i < len(document) && isNameChar(document[i]) {
+		i++
+	}
+	op.Name = document[start:i]
+	for i < len(document) && isSpace(document[i]) {
+		i++
+	}
+	if i < len(document) && document[i] == '(' {
+		end := strings.IndexByte(document[i:], ')')
+		if end < 0 {
+			return op, len(document)
+		}
+		definitions := strings.Join(strings.Fields(document[i+1:i+end]), "")
+		for _, match := range variableRegex.FindAllStringSubmatch(definitions, -1) {
+			op.Variables[match[1]] = match[2]
+		}
+		i += end + 1
+	}
+	return op, i
+}
+
+// ExtractOperations 从脚本中解析GraphQL的查询语句
+func ExtractOperations(js string) []*Operation {
+	var operations []*Operation
+	seen := map[string]bool{}
+	for _, match := range documentRegex.FindAllStringSubmatch(js, -1) {
+		document := match[1]
+		if document == "" {
+			document = match[2]
+		}
+		document = interpolationRegex.ReplaceAllString(document, "")
+		document = strings.NewReplacer(`\n`, "\n", `\t`, " ").Replace(document)
+		for _, op := range ParseDocument(document) {
+			op.Source = FromJavaScript
+			if seen[op.Key()] {
+				continue
+			}
+			seen[op.Key()] = true
+			operations = append(operations, op)
+		}
+	}
+	return operations
+}
+
+// ExtractEndpoints 从脚本中解析GraphQL的请求地址
+func ExtractEndpoints(js string) []string {
+	var endpoints []string
+	seen := map[string]bool{}
+	for _, match := range endpointRegex.FindAllStringSubmatch(js, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			endpoints = append(endpoints, match[1])
+		}
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// headerValue 不区分大小写获取请求头
+func headerValue(headers map[string]interface{}, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			if value, ok := value.(string); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// skipString 跳过字符串和块字符串,返回字符串结束的位置
+func skipString(document string, i int) int {
+	if strings.HasPrefix(document[i:], `"""`) {
+		if end := strings.Index(document[i+3:], `"""`); end >= 0 {
+			return i + 3 + end + 2
+		}
+		return len(document)
+	}
+	for i++; i < len(document); i++ {
+		if document[i] == '\\' {
+			i++
+		} else if document[i] == '"' {
+			return i
+		}
+	}
+	return len(document)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ','
+}
@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"testing"
+)
+
+func TestParseRequest(t *testing.T) {
+	u, _ := urllib.GetURL("https://example.com/graphql")
+	req := httplib.GetCrawlerRequest("POST", u, httplib.OptionsCrawler{
+		Headers:  map[string]interface{}{"Content-Type": "application/json"},
+		PostData: `{"operationName":"GetUser","variables":{"id":"1"},"query":"query GetUser($id: ID!, $first: Int = 10) { user(id: $id) { name query } }\nmutation DeleteUser($id: ID!) { deleteUser(id: $id) }"}`,
+	})
+	ops, ok := ParseRequest(req)
+	if !ok || len(ops) != 1 {
+		t.Fatalf("expected one operation, got %v", ops)
+	}
+	op := ops[0]
+	if op.Name != "GetUser" || op.Type != "query" || op.Endpoint != "https://example.com/graphql" || op.Variables["id"] != "ID!" || op.Variables["first"] != "Int" {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+
+	// 批量查询和匿名查询
+	req.PostData = `[{"query":"{ me { id } }"},{"query":"mutation { logout }"}]`
+	ops, ok = ParseRequest(req)
+	if !ok || len(ops) != 2 || ops[0].Type != "query" || ops[1].Type != "mutation" || ops[0].Key() == ops[1].Key() {
+		t.Fatalf("unexpected batch operations %v", ops)
+	}
+
+	req.PostData = `{"name":"query"}`
+	if _, ok = ParseRequest(req); ok {
+		t.Fatal("expected plain json not to be graphql")
+	}
+}
+
+func TestParseDocument_Fragments(t *testing.T) {
+	ops := ParseDocument(`# comment query Fake
+fragment UserFields on User { id "query" }
+subscription OnMessage($room: [ID!]!) { message(room: $room) { ...UserFields } }`)
+	if len(ops) != 1 || ops[0].Name != "OnMessage" || ops[0].Type != "subscription" || ops[0].Variables["room"] != "[ID!]!" {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+}
+
+func TestExtractOperations(t *testing.T) {
+	js := "const Q=gql`\n  query ListPosts($after: String) {\n posts(after: $after) { id ...${F} }\n }\n`;" +
+		`var m="mutation AddPost($title:String!){addPost(title:$title){id}}";fetch("/api/graphql")`
+	ops := ExtractOperations(js)
+	if len(ops) != 2 || ops[0].Name != "ListPosts" || ops[1].Name != "AddPost" || ops[1].Variables["title"] != "String!" {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+	if endpoints := ExtractEndpoints(js); len(endpoints) != 1 || endpoints[0] != "/api/graphql" {
+		t.Fatalf("unexpected endpoints %v", endpoints)
+	}
+}
+
+func TestParseIntrospection(t *testing.T) {
+	data := `{"data":{"__schema":{"queryType":{"name":"Query"},"mutationType":null,"subscriptionType":null,"types":[
+		{"name":"Query","fields":[{"name":"user","args":[{"name":"ids","type":{"kind":"NON_NULL","ofType":{"kind":"LIST","ofType":{"kind":"SCALAR","name":"ID"}}}}]}]},
+		{"name":"User","fields":[{"name":"id","args":[]}]}]}}}`
+	ops, err := ParseIntrospection("https://example.com/graphql", []byte(data))
+	if err != nil || len(ops) != 1 || ops[0].Name != "user" || ops[0].Type != "query" || ops[0].Variables["ids"] != "[ID]!" {
+		t.Fatalf("unexpected operations %+v %v", ops, err)
+	}
+}
@@ -0,0 +1,121 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/requests"
+)
+
+// IntrospectionQuery 只获取根类型的字段和参数的内省查询
+const IntrospectionQuery = `query IntrospectionQuery { __schema { queryType { name } mutationType { name } subscriptionType { name } types { name fields { name args { name type { ...TypeRef } } } } } } fragment TypeRef on __Type { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } }`
+
+// typeRef 内省结果中的类型引用
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String 按照GraphQL的语法输出类型,例如 [ID!]!
+func (t *typeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// introspectionResult 内省查询的响应
+type introspectionResult struct {
+	Data struct {
+		Schema struct {
+			QueryType        *struct{ Name string } `json:"queryType"`
+			MutationType     *struct{ Name string } `json:"mutationType"`
+			SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+			Types            []struct {
+				Name   string `json:"name"`
+				Fields []struct {
+					Name string `json:"name"`
+					Args []struct {
+						Name string   `json:"name"`
+						Type *typeRef `json:"type"`
+					} `json:"args"`
+				} `json:"fields"`
+			} `json:"types"`
+		} `json:"__schema"`
+	} `json:"data"`
+}
+
+// Introspect 向GraphQL地址发送内省查询,返回根类型上的全部操作
+func Introspect(endpoint string, headers map[string]string, proxy string) ([]*Operation, error) {
+	body, _ := json.Marshal(map[string]string{"query": IntrospectionQuery})
+	requestHeaders := map[string]string{}
+	for key, value := range headers {
+		requestHeaders[key] = value
+	}
+	requestHeaders["Content-Type"] = "application/json"
+	// schema通常较大,不限制响应的范围
+	requestHeaders["Range"] = "bytes=0-"
+	resp, err := requests.Request(enums.POST, endpoint, requestHeaders, body, &requests.RequestOptions{
+		Timeout: 10,
+		Proxy:   proxy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status code is %v", resp.StatusCode)
+	}
+	return ParseIntrospection(endpoint, resp.Body)
+}
+
+// ParseIntrospection 解析内省查询的响应
+func ParseIntrospection(endpoint string, data []byte) ([]*Operation, error) {
+	var result introspectionResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, errors.Wrap(err, "could not decode introspection result")
+	}
+	schema := result.Data.Schema
+	roots := map[string]string{}
+	if schema.QueryType != nil {
+		roots[schema.QueryType.Name] = "query"
+	}
+	if schema.MutationType != nil {
+		roots[schema.MutationType.Name] = "mutation"
+	}
+	if schema.SubscriptionType != nil {
+		roots[schema.SubscriptionType.Name] = "subscription"
+	}
+	if len(roots) == 0 {
+		return nil, errors.New("introspection is disabled")
+	}
+	var operations []*Operation
+	for _, t := range schema.Types {
+		opType, ok := roots[t.Name]
+		if !ok {
+			continue
+		}
+		for _, field := range t.Fields {
+			op := &Operation{
+				Endpoint:  endpoint,
+				Method:    enums.POST,
+				Name:      field.Name,
+				Type:      opType,
+				Variables: map[string]string{},
+				Source:    FromIntrospection,
+			}
+			for _, arg := range field.Args {
+				op.Variables[arg.Name] = arg.Type.String()
+			}
+			operations = append(operations, op)
+		}
+	}
+	return operations, nil
+}
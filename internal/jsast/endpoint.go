@@ -0,0 +1,564 @@
+package jsast
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 正则只能匹配完整的字符串字面量,这里解析脚本的语法树,计算字符串拼接和模板字符串的值,
+// 并识别 fetch、XMLHttpRequest.open、axios、$.ajax 和 navigator.sendBeacon 等请求调用
+
+// 请求调用的类型
+const (
+	CallFetch   = "fetch"
+	CallXHR     = "XMLHttpRequest"
+	CallAxios   = "axios"
+	CallJQuery  = "jQuery"
+	CallBeacon  = "sendBeacon"
+	CallLiteral = "literal" // 拼接或模板字符串得到的地址
+)
+
+// 请求体的编码方式
+const (
+	BodyJSON = "json"
+	BodyForm = "form"
+)
+
+// Endpoint 脚本中解析出来的一个请求
+type Endpoint struct {
+	Method   string   // 请求方法,无法确定时为GET
+	URL      string   // 请求地址,无法计算的部分使用占位值
+	BodyKeys []string // 请求体中的参数名
+	BodyType string   // 请求体的编码方式
+	Call     string   // 请求调用的类型
+}
+
+// placeholder 地址中无法计算部分的占位值
+const placeholder = "1"
+
+var (
+	// urlLikeRegex 看起来像请求地址的字符串
+	urlLikeRegex = regexp.MustCompile(`^(?:https?://|//|/|\./|\.\./)[^\s<>"'\\]*$`)
+	// mimeRegex MIME类型字符串,例如 text/javascript
+	mimeRegex = regexp.MustCompile(`^(?:application|text|image|audio|video|font|multipart)/[\w.+\-]+$`)
+	// axiosNames axios实例常用的名称
+	axiosNames = map[string]bool{"axios": true, "$axios": true, "$http": true}
+	// jqueryMethods jQuery的请求方法以及对应的请求方法
+	jqueryMethods = map[string]string{"ajax": "", "get": enums.GET, "getJSON": enums.GET, "post": enums.POST, "load": enums.GET}
+	// axiosMethods axios的请求方法
+	axiosMethods = map[string]string{"request": "", "get": enums.GET, "delete": enums.DELETE, "head": enums.HEAD, "options": enums.OPTIONS, "post": enums.POST, "put": enums.PUT, "patch": enums.PATCH}
+)
+
+// IsMimeType 判断字符串是否为MIME类型
+func IsMimeType(value string) bool {
+	return mimeRegex.MatchString(value)
+}
+
+// IsURLLike 判断字符串是否像请求地址,排除MIME类型等误报
+func IsURLLike(value string) bool {
+	if len(value) < 2 || IsMimeType(value) || strings.HasPrefix(value, "//#") {
+		return false
+	}
+	return urlLikeRegex.MatchString(value)
+}
+
+// ExtractEndpoints 解析脚本并提取其中的请求,相同方法和地址的请求只记录一次
+func ExtractEndpoints(source string) ([]*Endpoint, error) {
+	endpoints, _, err := Analyze(source)
+	return endpoints, err
+}
+
+// Analyze 解析脚本,返回其中的请求和对象字面量的属性名,属性名按照首次出现的顺序去重
+func Analyze(source string) ([]*Endpoint, []string, error) {
+	ast, err := js.Parse(parse.NewInputString(source), js.Options{})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not parse javascript")
+	}
+	v := &visitor{constants: map[*js.Var]js.IExpr{}, seen: map[string]*Endpoint{}, handled: map[js.IExpr]bool{}, keySet: map[string]bool{}}
+	// 先收集常量,变量的使用可能出现在声明之前
+	js.Walk(&constantVisitor{v}, ast)
+	js.Walk(v, ast)
+	return v.endpoints, v.keys, nil
+}
+
+// constantVisitor 收集初始值为字符串表达式的变量
+type constantVisitor struct {
+	v *visitor
+}
+
+func (c *constantVisitor) Enter(n js.INode) js.IVisitor {
+	if decl, ok := n.(*js.VarDecl); ok {
+		for _, item := range decl.List {
+			if variable, ok := item.Binding.(*js.Var); ok && item.Default != nil {
+				if _, exists := c.v.constants[variable]; exists {
+					// 多次赋值的变量无法确定值
+					c.v.constants[variable] = nil
+				} else {
+					c.v.constants[variable] = item.Default
+				}
+			}
+		}
+	}
+	return c
+}
+
+func (c *constantVisitor) Exit(js.INode) {}
+
+// visitor 遍历语法树并记录请求
+type visitor struct {
+	constants map[*js.Var]js.IExpr
+	seen      map[string]*Endpoint
+	handled   map[js.IExpr]bool // 已经作为请求调用参数处理过的表达式
+	endpoints []*Endpoint
+	keys      []string // 对象字面量的属性名
+	keySet    map[string]bool
+}
+
+func (v *visitor) Enter(n js.INode) js.IVisitor {
+	switch n := n.(type) {
+	case *js.CallExpr:
+		v.handleCall(n)
+	case *js.BinaryExpr:
+		if n.Op == js.AddToken {
+			if !v.handled[n] {
+				v.handleLiteral(n)
+			}
+			// 拼接表达式的子表达式不再单独计算,只继续查找其中的请求调用
+			v.walkOperands(n)
+			return nil
+		}
+	case *js.TemplateExpr:
+		if n.Tag == nil && len(n.List) > 0 && !v.handled[n] {
+			v.handleLiteral(n)
+		}
+	case *js.ObjectExpr:
+		for _, item := range n.List {
+			if item.Name == nil || item.Name.IsComputed() {
+				continue
+			}
+			if name := propertyName(item.Name); !v.keySet[name] {
+				v.keySet[name] = true
+				v.keys = append(v.keys, name)
+			}
+		}
+	}
+	return v
+}
+
+func (v *visitor) Exit(js.INode) {}
+
+// walkOperands 遍历拼接表达式中不是拼接的操作数
+func (v *visitor) walkOperands(expr *js.BinaryExpr) {
+	for _, operand := range []js.IExpr{expr.X, expr.Y} {
+		if binary, ok := unwrap(operand).(*js.BinaryExpr); ok && binary.Op == js.AddToken {
+			v.walkOperands(binary)
+		} else {
+			v.handled[operand] = true
+			js.Walk(v, operand)
+		}
+	}
+}
+
+// handleLiteral 记录拼接或模板字符串得到的地址
+func (v *visitor) handleLiteral(expr js.IExpr) {
+	if value, ok := v.evaluate(expr); ok && IsURLLike(value) {
+		v.add(&Endpoint{Method: enums.GET, URL: value, Call: CallLiteral})
+	}
+}
+
+// handleCall 识别请求调用
+func (v *visitor) handleCall(call *js.CallExpr) {
+	args := call.Args.List
+	if len(args) == 0 {
+		return
+	}
+	switch callee := call.X.(type) {
+	case *js.Var:
+		name := string(callee.Data)
+		switch {
+		case name == "fetch":
+			v.handleFetch(args)
+		case axiosNames[name]:
+			v.handleAxios("", args)
+		}
+	case *js.DotExpr:
+		method := string(callee.Y.Data)
+		object := rootName(callee.X)
+		switch {
+		case method == "fetch" && (object == "window" || object == "self" || object == "globalThis"):
+			v.handleFetch(args)
+		case method == "open" && len(args) >= 2:
+			// window.open 的第一个参数是地址,XMLHttpRequest.open 的第一个参数是请求方法
+			if verb, ok := v.evaluate(args[0].Value); ok && isHTTPMethod(verb) {
+				v.addCall(strings.ToUpper(verb), args[1].Value, nil, CallXHR)
+			}
+		case method == "sendBeacon" && object == "navigator":
+			var body js.IExpr
+			if len(args) > 1 {
+				body = args[1].Value
+			}
+			v.addCall(enums.POST, args[0].Value, body, CallBeacon)
+		case axiosNames[method]:
+			// this.$axios(config)
+			v.handleAxios("", args)
+		case axiosNames[object]:
+			if verb, ok := axiosMethods[method]; ok {
+				v.handleAxios(verb, args)
+			}
+		case object == "$" || object == "jQuery":
+			if verb, ok := jqueryMethods[method]; ok {
+				v.handleJQuery(verb, args)
+			}
+		}
+	}
+}
+
+// handleFetch fetch(url, {method, body})
+func (v *visitor) handleFetch(args []js.Arg) {
+	method := enums.GET
+	var body js.IExpr
+	if len(args) > 1 {
+		if options, ok := unwrap(args[1].Value).(*js.ObjectExpr); ok {
+			if value, ok := v.evaluate(property(options, "method")); ok && isHTTPMethod(value) {
+				method = strings.ToUpper(value)
+			}
+			body = property(options, "body")
+		}
+	}
+	v.addCall(method, args[0].Value, body, CallFetch)
+}
+
+// handleAxios axios(config)、axios(url, config)、axios.get(url, config) 和 axios.post(url, data, config)
+func (v *visitor) handleAxios(method string, args []js.Arg) {
+	var urlExpr, body js.IExpr
+	config, isConfig := unwrap(args[0].Value).(*js.ObjectExpr)
+	if isConfig {
+		urlExpr = property(config, "url")
+		body = property(config, "data")
+	} else {
+		urlExpr = args[0].Value
+		if len(args) > 1 {
+			switch method {
+			case enums.POST, enums.PUT, enums.PATCH:
+				body = args[1].Value
+			default:
+				config, isConfig = unwrap(args[1].Value).(*js.ObjectExpr)
+			}
+		}
+	}
+	if method == "" {
+		method = enums.GET
+		if isConfig {
+			if value, ok := v.evaluate(property(config, "method")); ok && isHTTPMethod(value) {
+				method = strings.ToUpper(value)
+			}
+		}
+	}
+	// 请求参数也作为GET请求的参数名
+	if body == nil && isConfig {
+		body = property(config, "params")
+	}
+	v.addCall(method, urlExpr, body, CallAxios)
+}
+
+// handleJQuery $.ajax({url, type, data})、$.ajax(url, settings)、$.get(url, data) 和 $.post(url, data)
+func (v *visitor) handleJQuery(method string, args []js.Arg) {
+	var urlExpr, body js.IExpr
+	settings, isSettings := unwrap(args[0].Value).(*js.ObjectExpr)
+	if isSettings {
+		urlExpr = property(settings, "url")
+	} else {
+		urlExpr = args[0].Value
+		if len(args) > 1 {
+			if method == "" {
+				settings, isSettings = unwrap(args[1].Value).(*js.ObjectExpr)
+			} else {
+				body = args[1].Value
+			}
+		}
+	}
+	if isSettings {
+		body = property(settings, "data")
+		if method == "" {
+			method = enums.GET
+			for _, key := range []string{"method", "type"} {
+				if value, ok := v.evaluate(property(settings, key)); ok && isHTTPMethod(value) {
+					method = strings.ToUpper(value)
+					break
+				}
+			}
+		}
+	}
+	if method == "" {
+		method = enums.GET
+	}
+	v.addCall(method, urlExpr, body, CallJQuery)
+}
+
+// addCall 计算请求地址和请求体参数名并记录请求
+func (v *visitor) addCall(method string, urlExpr js.IExpr, body js.IExpr, call string) {
+	if urlExpr == nil {
+		return
+	}
+	v.handled[urlExpr] = true
+	value, ok := v.evaluate(urlExpr)
+	if !ok || value == "" || strings.ContainsAny(value, " \n<>") {
+		return
+	}
+	endpoint := &Endpoint{Method: method, URL: value, Call: call}
+	endpoint.BodyKeys, endpoint.BodyType = v.bodyKeys(body, call)
+	v.add(endpoint)
+}
+
+// add 记录请求,相同方法和地址的请求合并参数名
+func (v *visitor) add(endpoint *Endpoint) {
+	key := endpoint.Method + " " + endpoint.URL
+	if old, ok := v.seen[key]; ok {
+		for _, name := range endpoint.BodyKeys {
+			if !contains(old.BodyKeys, name) {
+				old.BodyKeys = append(old.BodyKeys, name)
+			}
+		}
+		if old.Call == CallLiteral {
+			old.Call = endpoint.Call
+		}
+		if old.BodyType == "" {
+			old.BodyType = endpoint.BodyType
+		}
+		return
+	}
+	v.seen[key] = endpoint
+	v.endpoints = append(v.endpoints, endpoint)
+}
+
+// bodyKeys 获取请求体中的参数名,支持对象字面量、JSON.stringify(对象) 和 new URLSearchParams(对象)
+func (v *visitor) bodyKeys(body js.IExpr, call string) ([]string, string) {
+	body = v.resolve(unwrap(body))
+	if body == nil {
+		return nil, ""
+	}
+	bodyType := BodyForm
+	// axios默认以json格式发送对象
+	if call == CallAxios {
+		bodyType = BodyJSON
+	}
+	switch b := body.(type) {
+	case *js.CallExpr:
+		if dot, ok := b.X.(*js.DotExpr); ok && rootName(dot.X) == "JSON" && string(dot.Y.Data) == "stringify" && len(b.Args.List) > 0 {
+			body, bodyType = v.resolve(unwrap(b.Args.List[0].Value)), BodyJSON
+		}
+	case *js.NewExpr:
+		if name, ok := b.X.(*js.Var); ok && string(name.Data) == "URLSearchParams" && b.Args != nil && len(b.Args.List) > 0 {
+			body, bodyType = v.resolve(unwrap(b.Args.List[0].Value)), BodyForm
+		}
+	}
+	if object, ok := body.(*js.ObjectExpr); ok {
+		var keys []string
+		for _, item := range object.List {
+			if item.Name == nil || item.Name.IsComputed() {
+				continue
+			}
+			if name := propertyName(item.Name); name != "" && !contains(keys, name) {
+				keys = append(keys, name)
+			}
+		}
+		sort.Strings(keys)
+		return keys, bodyType
+	}
+	// 字符串形式的表单 a=1&b=2
+	if value, ok := v.evaluate(body); ok && strings.Contains(value, "=") {
+		var keys []string
+		for _, pair := range strings.Split(value, "&") {
+			if name := strings.SplitN(pair, "=", 2)[0]; name != "" && !contains(keys, name) {
+				keys = append(keys, name)
+			}
+		}
+		return keys, BodyForm
+	}
+	return nil, ""
+}
+
+// evaluate 计算字符串表达式的值,无法计算的部分开头为空,其余位置使用占位值,没有任何可计算部分时返回false
+func (v *visitor) evaluate(expr js.IExpr) (string, bool) {
+	var sb strings.Builder
+	known := v.evaluateTo(&sb, expr, 0)
+	return sb.String(), known
+}
+
+func (v *visitor) evaluateTo(sb *strings.Builder, expr js.IExpr, depth int) bool {
+	if depth > 10 {
+		return false
+	}
+	expr = unwrap(expr)
+	switch e := expr.(type) {
+	case *js.LiteralExpr:
+		switch e.TokenType {
+		case js.StringToken:
+			sb.WriteString(unquote(e.Data))
+			return true
+		case js.DecimalToken, js.IntegerToken:
+			sb.Write(e.Data)
+			return true
+		}
+	case *js.TemplateExpr:
+		if e.Tag != nil {
+			break
+		}
+		known := false
+		for _, part := range e.List {
+			if text := templateText(part.Value); text != "" {
+				sb.WriteString(text)
+				known = true
+			}
+			if v.evaluateTo(sb, part.Expr, depth+1) {
+				known = true
+			} else if sb.Len() > 0 {
+				sb.WriteString(placeholder)
+			}
+		}
+		if text := templateText(e.Tail); text != "" {
+			sb.WriteString(text)
+			known = true
+		}
+		return known
+	case *js.BinaryExpr:
+		if e.Op != js.AddToken {
+			break
+		}
+		left := v.evaluateTo(sb, e.X, depth+1)
+		if !left && sb.Len() > 0 {
+			sb.WriteString(placeholder)
+		}
+		right := v.evaluateTo(sb, e.Y, depth+1)
+		if !right && sb.Len() > 0 {
+			sb.WriteString(placeholder)
+		}
+		return left || right
+	case *js.Var:
+		if value := v.resolve(e); value != nil && value != js.IExpr(e) {
+			return v.evaluateTo(sb, value, depth+1)
+		}
+	}
+	return false
+}
+
+// resolve 将变量替换为其初始值
+func (v *visitor) resolve(expr js.IExpr) js.IExpr {
+	for i := 0; i < 5; i++ {
+		variable, ok := expr.(*js.Var)
+		if !ok {
+			return expr
+		}
+		for variable.Link != nil {
+			variable = variable.Link
+		}
+		value := v.constants[variable]
+		if value == nil {
+			return expr
+		}
+		expr = unwrap(value)
+	}
+	return expr
+}
+
+// unwrap 去掉括号
+func unwrap(expr js.IExpr) js.IExpr {
+	for {
+		group, ok := expr.(*js.GroupExpr)
+		if !ok {
+			return expr
+		}
+		expr = group.X
+	}
+}
+
+// property 获取对象字面量中指定名称的属性值
+func property(object *js.ObjectExpr, name string) js.IExpr {
+	for _, item := range object.List {
+		if item.Name != nil && !item.Name.IsComputed() && propertyName(item.Name) == name {
+			if item.Value == nil {
+				return nil
+			}
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// propertyName 获取属性名,字符串形式的属性名去掉引号
+func propertyName(name *js.PropertyName) string {
+	if name.Literal.TokenType == js.StringToken {
+		return unquote(name.Literal.Data)
+	}
+	return string(name.Literal.Data)
+}
+
+// rootName 获取成员表达式最左侧的变量名,例如 this.$axios.get 返回 $axios
+func rootName(expr js.IExpr) string {
+	switch e := expr.(type) {
+	case *js.Var:
+		return string(e.Data)
+	case *js.DotExpr:
+		if _, ok := e.X.(*js.LiteralExpr); ok {
+			return string(e.Y.Data)
+		}
+		if name := rootName(e.X); name != "" && name != "this" && name != "window" && name != "self" && name != "globalThis" {
+			return name
+		}
+		return string(e.Y.Data)
+	}
+	return ""
+}
+
+// templateText 去掉模板字符串片段中的 ` ${ } 分隔符
+func templateText(raw []byte) string {
+	text := string(raw)
+	text = strings.TrimPrefix(text, "`")
+	text = strings.TrimPrefix(text, "}")
+	text = strings.TrimSuffix(text, "`")
+	text = strings.TrimSuffix(text, "${")
+	return text
+}
+
+// unquote 去掉字符串字面量的引号并处理转义字符
+func unquote(raw []byte) string {
+	if len(raw) < 2 {
+		return ""
+	}
+	quote := raw[0]
+	text := string(raw[1 : len(raw)-1])
+	if !strings.Contains(text, "\\") {
+		return text
+	}
+	// 转换为Go的双引号字符串再解析
+	if quote == '\'' {
+		text = strings.ReplaceAll(strings.ReplaceAll(text, `\'`, `'`), `"`, `\"`)
+	}
+	if value, err := strconv.Unquote(`"` + text + `"`); err == nil {
+		return value
+	}
+	return strings.ReplaceAll(text, "\\", "")
+}
+
+func isHTTPMethod(value string) bool {
+	switch strings.ToUpper(value) {
+	case enums.GET, enums.POST, enums.PUT, enums.DELETE, enums.HEAD, enums.OPTIONS, enums.PATCH:
+		return true
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
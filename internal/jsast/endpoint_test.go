@@ -0,0 +1,102 @@
+package jsast
+
+import (
+	"strings"
+	"testing"
+)
+
+func findEndpoint(endpoints []*Endpoint, method string, url string) *Endpoint {
+	for _, endpoint := range endpoints {
+		if endpoint.Method == method && endpoint.URL == url {
+			return endpoint
+		}
+	}
+	return nil
+}
+
+func TestExtractEndpoints_Calls(t *testing.T) {
+	js := `
+const API = "/api/v2";
+function load(id) {
+	fetch(API + "/users/" + id + "/profile", {method: "post", body: JSON.stringify({name: n, "email": e})});
+	var xhr = new XMLHttpRequest();
+	xhr.open("PUT", ` + "`${API}/orders/${id}`" + `);
+	window.open("/help", "_blank");
+	axios.post("/api/login", {username: u, password: p});
+	this.$axios({url: "/api/search", method: "get", params: {q: 1}});
+	$.ajax({url: "/legacy/save.php", type: "POST", data: "a=1&b=2"});
+	$.get("/legacy/list.php", {page: 1});
+	navigator.sendBeacon("/collect", new URLSearchParams({event: "view"}));
+}`
+	endpoints, err := ExtractEndpoints(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []struct {
+		method, url, call, bodyType, keys string
+	}{
+		{"POST", "/api/v2/users/1/profile", CallFetch, BodyJSON, "email,name"},
+		{"PUT", "/api/v2/orders/1", CallXHR, "", ""},
+		{"POST", "/api/login", CallAxios, BodyJSON, "password,username"},
+		{"GET", "/api/search", CallAxios, BodyJSON, "q"},
+		{"POST", "/legacy/save.php", CallJQuery, BodyForm, "a,b"},
+		{"GET", "/legacy/list.php", CallJQuery, BodyForm, "page"},
+		{"POST", "/collect", CallBeacon, BodyForm, "event"},
+	} {
+		endpoint := findEndpoint(endpoints, item.method, item.url)
+		if endpoint == nil {
+			t.Fatalf("missing %s %s in %+v", item.method, item.url, endpoints)
+		}
+		if endpoint.Call != item.call || endpoint.BodyType != item.bodyType || strings.Join(endpoint.BodyKeys, ",") != item.keys {
+			t.Fatalf("unexpected endpoint %+v", endpoint)
+		}
+	}
+	if findEndpoint(endpoints, "GET", "/help") != nil {
+		t.Fatal("window.open should not be treated as XMLHttpRequest.open")
+	}
+}
+
+func TestExtractEndpoints_Literals(t *testing.T) {
+	js := "var base = location.origin; var u = base + '/static/' + name + '.json';" +
+		"var t = `/report/${year}/summary?type=${type}`; var m = 'text/' + 'javascript'; var s = 'a' + 'b';"
+	endpoints, err := ExtractEndpoints(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findEndpoint(endpoints, "GET", "/static/1.json") == nil || findEndpoint(endpoints, "GET", "/report/1/summary?type=1") == nil {
+		t.Fatalf("unexpected endpoints %+v", endpoints)
+	}
+	if len(endpoints) != 2 {
+		t.Fatalf("expected only url like literals, got %+v", endpoints)
+	}
+	if _, err = ExtractEndpoints("function ("); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestIsURLLike(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"/api/users":             true,
+		"https://example.com/a":  true,
+		"./chunk.js":             true,
+		"text/javascript":        false,
+		"application/json":       false,
+		"/":                      false,
+		"//# sourceMappingURL=a": false,
+		"hello world":            false,
+	} {
+		if IsURLLike(value) != expected {
+			t.Fatalf("%s: expected %v", value, expected)
+		}
+	}
+}
+
+func TestAnalyze_ObjectKeys(t *testing.T) {
+	_, keys, err := Analyze(`var o = {pageSize: 10, "order_by": "id", [dynamic]: 1}; post({pageSize: 20, keyword: q});`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "pageSize,order_by,keyword" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
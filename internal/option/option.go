@@ -40,13 +40,21 @@ func initSet(suffix []string) mapset.Set {
 }
 
 type TaskOptions struct {
-	MaxCrawlerCount         int                    // 最大爬取的数量
-	FilterMode              string                 // 过滤模式,支持simple(普通),smart(智能),strict(严格)
+	MaxCrawlerCount         int                    // 最大爬取的数量,多个设备配置共享同一个数量,不会按照设备数量成倍增加
+	FilterMode              string                 // 过滤模式,支持simple(普通),smart(智能),strict(严格)以及通过pkg/filter.Register注册的过滤器
+	FilterStore             string                 // 过滤器的去重存储,支持set(集合)和bloom(布隆过滤器),大规模爬取时使用bloom限制内存
+	FilterFalsePositive     float64                // 布隆过滤器的误判率,误判的请求会被当作重复请求过滤
+	FilterStatMaxKeys       int                    // 智能过滤最多记录的统计项数量,超过后不再新建统计项
+	FilterReport            bool                   // 是否记录被过滤的请求及原因,生成按照原因分组的过滤报告
+	NearDuplicate           bool                   // 是否按照渲染后页面的相似度折叠地址模板
+	NearDuplicateMaxPages   int                    // 同一个地址模板下几乎相同的页面达到此数量后不再扩展该模板
+	NearDuplicateDistance   int                    // 页面文本和DOM结构simhash的最大汉明距离,不超过时视为几乎相同
 	ExtraHeaders            map[string]interface{} // 额外的请求头
 	ExtraHeadersString      string                 // 额外请求头字符串
 	AllDomainReturn         bool                   // 全部域名收集
 	SubDomainReturn         bool                   // 子域名收集
 	NoHeadless              bool                   // chromedp的无头模式
+	DisableSiteIsolation    bool                   // 关闭浏览器的站点隔离,跨域iframe在同一个渲染进程中才能进入其中爬取,默认关闭
 	DomContentLoadedTimeout time.Duration          // dom节点加载超时
 	TabRunTimeout           time.Duration          // 单个tab页打开超时
 	PathFuzz                bool                   // 是否通过字典进行路径fuzz
@@ -55,15 +63,39 @@ type TaskOptions struct {
 	PathFormSitemap         bool                   // 解析网站地图找出路径
 	MaxTabCount             int                    // 允许开启的最大标签页数量,即同时爬取的数量
 	ChromiumPath            string                 // chromium程序的启动路径
-	EventTriggerMode        string                 // 事件触发的调用方式： 异步 或 顺序
+	EventTriggerMode        string                 // 事件触发的调用方式： 异步、顺序 或 可信事件(trusted)
 	EventTriggerInterval    time.Duration          // 事件触发的间隔
 	BeforeExitDelay         time.Duration          // 退出前的等待时间，等待DOM渲染，等待XHR发出捕获
 	EncodeURLWithCharset    bool                   // 使用检测到的字符集自动编码URL
 	IgnoreKeywords          []string               // 忽略的关键字，匹配上之后将不再扫描且不发送请求
+	DangerKeywords          []string               // 危险操作关键字,元素的文本、aria-label、id、class、表单提交地址匹配上之后不会被点击或提交
+	ReadOnly                bool                   // 只读模式,浏览器中只允许发出GET、HEAD、OPTIONS请求,其他请求被阻止但仍然记录
 	Proxy                   string                 // 请求代理
 	CustomFormValues        map[string]string      // 自定义表单填充参数
 	CustomFormKeywordValues map[string]string      // 自定义表单关键词填充内容
+	BypassFormValidation    bool                   // 提交表单前关闭浏览器表单校验并清除自定义校验错误,默认关闭,开启后会提交不符合校验规则的表单
 	CustomDefinedRegex      []string               // 用户自定义正则,这个正则会在获取到js,css,json等文件被发现时被执行
+	ArchiveDir              string                 // 渲染后DOM的归档目录,为空时不归档
+	ArchiveSnapshot         bool                   // 是否同时归档完整的DOMSnapshot
+	ArchiveCompress         bool                   // 归档文件是否使用gzip压缩
+	ArchiveMaxSize          int                    // 单个归档文件的最大字节数,HTML超过时截断,DOMSnapshot超过时丢弃
+	BodyStoreDir            string                 // 响应体存储目录,为空时不存储,文件名为响应体的SHA-256
+	BodyStoreMaxSize        map[string]int         // 每种MIME类型的响应体最大字节数,键支持 text/html、image/* 和 *
+	BodyStoreAllowMime      []string               // 允许存储的MIME类型,为空时允许全部
+	BodyStoreDenyMime       []string               // 不允许存储的MIME类型,优先于允许列表
+	WebSocketMaxFrames      int                    // 每个WebSocket连接最多记录的消息帧数
+	GraphQLIntrospection    bool                   // 是否向发现的GraphQL地址发送内省查询
+	SourceMap               bool                   // 是否获取脚本的source map并从还原的源码中提取链接和敏感信息
+	SourceMapDir            string                 // 还原源码的保存目录,为空时不保存
+	DeviceProfiles          []string               // 使用的设备配置名称,多个时依次在每个设备下爬取并合并结果
+	DeviceProfilePath       string                 // 自定义设备配置的json文件路径
+	Languages               []string               // 浏览器语言列表,同时决定navigator.languages和Accept-Language
+	Timezone                string                 // 浏览器时区,例如 Asia/Shanghai
+	SPAExplore              bool                   // 是否开启单页应用状态探索,按照DOM状态广度优先执行页面动作
+	ExploreMaxActions       int                    // 状态探索时每个tab页最多执行的动作数
+	ClientRoute             bool                   // 是否解析前端路由并通过应用自身的路由访问
+	InfiniteScroll          bool                   // 是否逐步滚动页面触发无限滚动、懒加载和"加载更多"按钮
+	ScrollMaxCount          int                    // 无限滚动时最多滚动的次数
 	Custom401Auth           struct {               // 用户自定义401认证
 		Username string
 		Password string
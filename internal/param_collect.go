@@ -0,0 +1,273 @@
+package internal
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 参数名的来源
+const (
+	ParamFromQuery  = "query"
+	ParamFromBody   = "body"
+	ParamFromForm   = "form"
+	ParamFromHidden = "hidden"
+	ParamFromCookie = "cookie"
+	ParamFromScript = "script"
+)
+
+var (
+	// paramNameRegex 请求和表单中的参数名
+	paramNameRegex = regexp.MustCompile(`^[A-Za-z0-9_$][\w$\-.\[\]]{0,63}$`)
+	// scriptParamRegex 脚本中的属性名噪音较多,只保留普通的标识符
+	scriptParamRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_\-]{1,39}$`)
+	// setCookieRegex Set-Cookie中的Cookie名称,多个Set-Cookie以换行或逗号连接
+	setCookieRegex = regexp.MustCompile(`(?:^|[\n,])\s*([^=;,\s]+)=`)
+	// cookieAttributes Set-Cookie中的属性名
+	cookieAttributes = map[string]bool{"path": true, "domain": true, "expires": true, "max-age": true, "samesite": true, "priority": true}
+)
+
+// ParamEntry 一个参数名及其出现的次数和来源
+type ParamEntry struct {
+	Name    string   `json:"name"`
+	Count   int      `json:"count"`
+	Sources []string `json:"sources"`
+}
+
+// EndpointParams 一个请求地址上出现过的参数
+type EndpointParams struct {
+	Method string        `json:"method"`
+	URL    string        `json:"url"` // 不带查询参数的地址
+	Params []*ParamEntry `json:"params"`
+}
+
+// ParamCollect 收集爬取过程中出现的参数名,生成每个地址的参数清单和全站去重的参数字典
+type ParamCollect struct {
+	params    map[string]*ParamEntry
+	endpoints map[string]*EndpointParams
+	lock      sync.Mutex
+}
+
+// NewParamCollect 新建参数收集
+func NewParamCollect() *ParamCollect {
+	return &ParamCollect{
+		params:    map[string]*ParamEntry{},
+		endpoints: map[string]*EndpointParams{},
+	}
+}
+
+// AddRequest 收集请求的查询参数、请求体参数和Cookie名称
+func (p *ParamCollect) AddRequest(req *httplib.RequestCrawler) {
+	var endpoint *EndpointParams
+	if req.URL != nil {
+		endpoint = p.endpoint(req.Method, req.URL.NoQueryUrl())
+		for name := range req.URL.QueryMap() {
+			p.add(endpoint, name, ParamFromQuery)
+		}
+	}
+	if req.PostData != "" {
+		if _, err := req.ContentType(); err == nil {
+			for _, name := range flattenKeys(req.CrawlerPostData(), 0) {
+				p.add(endpoint, name, ParamFromBody)
+			}
+		}
+	}
+	if cookie, ok := req.Headers["Cookie"].(string); ok {
+		for _, name := range CookieNames(cookie) {
+			p.add(nil, name, ParamFromCookie)
+		}
+	}
+	if req.Response != nil {
+		for key, value := range req.Response.Headers {
+			if strings.EqualFold(key, "Set-Cookie") {
+				for _, name := range SetCookieNames(value) {
+					p.add(nil, name, ParamFromCookie)
+				}
+			}
+		}
+	}
+}
+
+// AddForm 收集表单的字段名,隐藏字段单独标记来源
+func (p *ParamCollect) AddForm(form *httplib.Form) {
+	action := form.Action
+	if action == "" {
+		action = form.URL
+	}
+	var parents []urllib.URL
+	if page, err := urllib.GetURL(form.URL); err == nil {
+		parents = append(parents, *page)
+	}
+	var endpoint *EndpointParams
+	if u, err := urllib.GetURL(action, parents...); err == nil {
+		method := strings.ToUpper(form.Method)
+		if method == "" {
+			method = "GET"
+		}
+		endpoint = p.endpoint(method, u.NoQueryUrl())
+	}
+	for _, field := range form.Fields {
+		source := ParamFromForm
+		if strings.EqualFold(field.Type, "hidden") {
+			source = ParamFromHidden
+		}
+		p.add(endpoint, field.Name, source)
+	}
+}
+
+// AddScriptParams 收集脚本中的属性名,这些参数不属于某个具体的地址
+func (p *ParamCollect) AddScriptParams(names []string) {
+	for _, name := range names {
+		if scriptParamRegex.MatchString(name) {
+			p.add(nil, name, ParamFromScript)
+		}
+	}
+}
+
+// Wordlist 按照出现次数从多到少排列的参数字典
+func (p *ParamCollect) Wordlist() []string {
+	var words []string
+	for _, entry := range p.Entries() {
+		words = append(words, entry.Name)
+	}
+	return words
+}
+
+// Entries 按照出现次数从多到少排列的全部参数,次数相同时按照名称排列
+func (p *ParamCollect) Entries() []*ParamEntry {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	entries := make([]*ParamEntry, 0, len(p.params))
+	for _, entry := range p.params {
+		entries = append(entries, entry)
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// Inventory 每个地址的参数清单,按照地址和请求方法排列
+func (p *ParamCollect) Inventory() []*EndpointParams {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	inventory := make([]*EndpointParams, 0, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		if len(endpoint.Params) == 0 {
+			continue
+		}
+		sortEntries(endpoint.Params)
+		inventory = append(inventory, endpoint)
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		if inventory[i].URL != inventory[j].URL {
+			return inventory[i].URL < inventory[j].URL
+		}
+		return inventory[i].Method < inventory[j].Method
+	})
+	return inventory
+}
+
+// endpoint 获取或新建地址的参数清单
+func (p *ParamCollect) endpoint(method string, url string) *EndpointParams {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	key := method + " " + url
+	endpoint, ok := p.endpoints[key]
+	if !ok {
+		endpoint = &EndpointParams{Method: method, URL: url}
+		p.endpoints[key] = endpoint
+	}
+	return endpoint
+}
+
+// add 记录一次参数名的出现,endpoint为空时只记录到全站字典
+func (p *ParamCollect) add(endpoint *EndpointParams, name string, source string) {
+	if !paramNameRegex.MatchString(name) {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	entry, ok := p.params[name]
+	if !ok {
+		entry = &ParamEntry{Name: name}
+		p.params[name] = entry
+	}
+	countEntry(entry, source)
+	if endpoint == nil {
+		return
+	}
+	for _, item := range endpoint.Params {
+		if item.Name == name {
+			countEntry(item, source)
+			return
+		}
+	}
+	item := &ParamEntry{Name: name}
+	countEntry(item, source)
+	endpoint.Params = append(endpoint.Params, item)
+}
+
+func countEntry(entry *ParamEntry, source string) {
+	entry.Count++
+	for _, s := range entry.Sources {
+		if s == source {
+			return
+		}
+	}
+	entry.Sources = append(entry.Sources, source)
+}
+
+func sortEntries(entries []*ParamEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// flattenKeys 获取请求体中的全部参数名,json嵌套的对象同时记录子对象的键名
+func flattenKeys(data map[string]interface{}, depth int) []string {
+	var keys []string
+	for key, value := range data {
+		keys = append(keys, key)
+		if depth >= 3 {
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			keys = append(keys, flattenKeys(v, depth+1)...)
+		case []interface{}:
+			for _, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					keys = append(keys, flattenKeys(m, depth+1)...)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// CookieNames 解析Cookie请求头中的Cookie名称
+func CookieNames(cookie string) []string {
+	var names []string
+	for _, pair := range strings.Split(cookie, ";") {
+		if name := strings.TrimSpace(strings.SplitN(pair, "=", 2)[0]); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SetCookieNames 解析Set-Cookie响应头中的Cookie名称,忽略Path、Expires等属性
+func SetCookieNames(setCookie string) []string {
+	var names []string
+	for _, match := range setCookieRegex.FindAllStringSubmatch(setCookie, -1) {
+		if !cookieAttributes[strings.ToLower(match[1])] {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"reflect"
+	"testing"
+)
+
+func TestParamCollect(t *testing.T) {
+	p := NewParamCollect()
+	u, _ := urllib.GetURL("https://example.com/api/user?id=1&token=abc")
+	req := httplib.GetCrawlerRequest("POST", u, httplib.OptionsCrawler{
+		Headers:  map[string]interface{}{"Content-Type": "application/json", "Cookie": "session=1; lang=zh"},
+		PostData: `{"id":1,"profile":{"nickname":"a"},"tags":[{"label":"x"}]}`,
+	})
+	req.Response = &httplib.ResponseInfo{Headers: map[string]string{"set-cookie": "csrf=1; Path=/; Expires=Wed, 21 Oct 2015 07:28:00 GMT\nremember=0; HttpOnly"}}
+	p.AddRequest(req)
+	p.AddForm(&httplib.Form{URL: "https://example.com/login", Action: "/login.php?from=home", Method: "post", Fields: []httplib.FormField{
+		{Name: "username", Type: "text"}, {Name: "csrf", Type: "hidden"}, {Name: "", Type: "submit"},
+	}})
+	p.AddScriptParams([]string{"pageSize", "id", "a", "__proto__ x"})
+
+	words := p.Wordlist()
+	expected := []string{"id", "csrf", "label", "lang", "nickname", "pageSize", "profile", "remember", "session", "tags", "token", "username"}
+	if !reflect.DeepEqual(words, expected) {
+		t.Fatalf("unexpected wordlist %v", words)
+	}
+	for _, entry := range p.Entries() {
+		if entry.Name == "id" && (entry.Count != 3 || !reflect.DeepEqual(entry.Sources, []string{ParamFromQuery, ParamFromBody, ParamFromScript})) {
+			t.Fatalf("unexpected id entry %+v", entry)
+		}
+		if entry.Name == "csrf" && !reflect.DeepEqual(entry.Sources, []string{ParamFromCookie, ParamFromHidden}) {
+			t.Fatalf("unexpected csrf entry %+v", entry)
+		}
+	}
+
+	inventory := p.Inventory()
+	if len(inventory) != 2 || inventory[0].URL != "https://example.com/api/user" || inventory[1].URL != "https://example.com/login.php" || inventory[1].Method != "POST" {
+		t.Fatalf("unexpected inventory %+v", inventory)
+	}
+	// Cookie和脚本中的参数不属于具体的地址
+	if len(inventory[0].Params) != 6 || inventory[0].Params[0].Name != "id" || inventory[0].Params[0].Count != 2 {
+		t.Fatalf("unexpected endpoint params %+v", inventory[0].Params)
+	}
+}
@@ -0,0 +1,245 @@
+package sourcemap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sairson/crawlergo/internal/engine/enums"
+	"github.com/sairson/crawlergo/internal/engine/requests"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 生产环境的脚本经常仍然带有source map,这里获取source map并还原出原始的源码文件,
+// 原始源码中保留了被压缩掉的接口地址、注释和配置,比压缩后的脚本更适合提取链接和敏感信息
+
+// SourceMap source map文件中需要的字段
+type SourceMap struct {
+	Version        int       `json:"version"`
+	File           string    `json:"file"`
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"` // 没有内容的源码为null
+	Sections       []struct {
+		Map *SourceMap `json:"map"`
+	} `json:"sections"` // 索引形式的source map
+}
+
+// Source 一个还原出来的源码文件
+type Source struct {
+	Path    string // 清理后的相对路径
+	Content string
+}
+
+// Secret 源码中匹配到的敏感信息
+type Secret struct {
+	Type  string `json:"type"`  // 敏感信息的类型
+	Value string `json:"value"` // 匹配到的内容
+	File  string `json:"file"`  // 所在的源码文件
+}
+
+// Record 一个source map的处理记录
+type Record struct {
+	ScriptURL string    `json:"script_url"` // 引用source map的脚本
+	MapURL    string    `json:"map_url"`    // source map地址,内联时为data:
+	Dir       string    `json:"dir"`        // 源码保存的目录,没有保存时为空
+	Files     []string  `json:"files"`      // 还原出来的源码文件
+	Secrets   []*Secret `json:"secrets"`    // 源码中匹配到的敏感信息
+}
+
+var (
+	// commentRegex 脚本或样式末尾的 sourceMappingURL 注释
+	commentRegex = regexp.MustCompile(`(?m)(?://|/\*)[#@]\s*sourceMappingURL\s*=\s*([^\s*'"]+)`)
+	// schemeRegex 源码路径中的 webpack:// 等前缀
+	schemeRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.\-]*:/*`)
+	// unsafeRegex 文件名中不允许出现的字符
+	unsafeRegex = regexp.MustCompile(`[<>:"|?*\x00-\x1f]`)
+	// secretRegex 预先编译的敏感信息正则
+	secretRegex = map[string]*regexp.Regexp{}
+)
+
+func init() {
+	for name, expr := range enums.SecretRegex {
+		secretRegex[name] = regexp.MustCompile(expr)
+	}
+}
+
+// FindURL 获取脚本引用的source map地址,响应头 SourceMap、X-SourceMap 优先于脚本中的注释
+func FindURL(body string, headers map[string]string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, "SourceMap") || strings.EqualFold(key, "X-SourceMap") {
+			if value = strings.TrimSpace(value); value != "" {
+				return value
+			}
+		}
+	}
+	// 只取最后一个注释,打包后的脚本中可能包含其他模块残留的注释
+	matches := commentRegex.FindAllStringSubmatch(body, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
+
+// Parse 解析source map,兼容开头带有 )]}' 防护前缀的文件
+func Parse(data []byte) (*SourceMap, error) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, ")]}") {
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		}
+	}
+	var m SourceMap
+	if err := json.Unmarshal([]byte(text), &m); err != nil {
+		return nil, errors.Wrap(err, "could not decode source map")
+	}
+	if len(m.Sources) == 0 && len(m.Sections) == 0 {
+		return nil, errors.New("source map has no sources")
+	}
+	return &m, nil
+}
+
+// DecodeDataURL 解码内联在脚本中的 data: 形式的source map
+func DecodeDataURL(dataURL string) ([]byte, error) {
+	i := strings.IndexByte(dataURL, ',')
+	if !strings.HasPrefix(dataURL, "data:") || i < 0 {
+		return nil, errors.New("invalid data url")
+	}
+	meta, data := dataURL[5:i], dataURL[i+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	decoded, err := url.PathUnescape(data)
+	return []byte(decoded), err
+}
+
+// Fetch 获取并解析source map,.map 属于静态资源会被浏览器拦截,这里直接发送请求
+func Fetch(mapURL string, headers map[string]string, proxy string) (*SourceMap, error) {
+	if strings.HasPrefix(mapURL, "data:") {
+		data, err := DecodeDataURL(mapURL)
+		if err != nil {
+			return nil, err
+		}
+		return Parse(data)
+	}
+	requestHeaders := map[string]string{}
+	for key, value := range headers {
+		requestHeaders[key] = value
+	}
+	// source map通常较大,不限制响应的范围
+	requestHeaders["Range"] = "bytes=0-"
+	resp, err := requests.Get(mapURL, requestHeaders, &requests.RequestOptions{
+		Timeout: 10,
+		Proxy:   proxy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status code is %v", resp.StatusCode)
+	}
+	if len(resp.Body) > enums.SourceMapMaxSize {
+		return nil, errors.New("source map is too large")
+	}
+	return Parse(resp.Body)
+}
+
+// OriginalSources 返回带有内容的全部源码文件,索引形式的source map会展开每一段,相同路径只保留第一个
+func (m *SourceMap) OriginalSources() []Source {
+	var sources []Source
+	seen := map[string]bool{}
+	m.collect(&sources, seen, 0)
+	return sources
+}
+
+func (m *SourceMap) collect(sources *[]Source, seen map[string]bool, depth int) {
+	if depth > 3 {
+		return
+	}
+	for i, name := range m.Sources {
+		if i >= len(m.SourcesContent) || m.SourcesContent[i] == nil || *m.SourcesContent[i] == "" {
+			continue
+		}
+		p := SafePath(m.SourceRoot, name)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		*sources = append(*sources, Source{Path: p, Content: *m.SourcesContent[i]})
+	}
+	for _, section := range m.Sections {
+		if section.Map != nil {
+			section.Map.collect(sources, seen, depth+1)
+		}
+	}
+}
+
+// SafePath 将source map中的源码路径转换为安全的相对路径,去掉 webpack:// 等前缀、查询参数和 .. 路径
+func SafePath(root string, source string) string {
+	if root != "" && !schemeRegex.MatchString(source) && !strings.HasPrefix(source, "/") {
+		source = strings.TrimSuffix(root, "/") + "/" + source
+	}
+	source = schemeRegex.ReplaceAllString(source, "")
+	if i := strings.IndexAny(source, "?#"); i >= 0 {
+		source = source[:i]
+	}
+	source = strings.ReplaceAll(source, "\\", "/")
+	var parts []string
+	for _, part := range strings.Split(path.Clean("/"+source), "/") {
+		part = strings.TrimSpace(unsafeRegex.ReplaceAllString(part, "_"))
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// SaveTree 按照原始的目录结构保存源码文件,返回写入的文件路径
+func SaveTree(dir string, sources []Source) ([]string, error) {
+	var files []string
+	for _, source := range sources {
+		file := filepath.Join(dir, filepath.FromSlash(source.Path))
+		// SafePath已经去掉了 .. ,这里再确认一次不会写到目录之外
+		if rel, err := filepath.Rel(dir, file); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return files, err
+		}
+		if err := os.WriteFile(file, []byte(source.Content), 0644); err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// FindSecrets 在源码中匹配敏感信息,相同类型的相同内容只记录一次
+func FindSecrets(sources []Source) []*Secret {
+	names := make([]string, 0, len(secretRegex))
+	for name := range secretRegex {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var secrets []*Secret
+	seen := map[string]bool{}
+	for _, source := range sources {
+		for _, name := range names {
+			for _, value := range secretRegex[name].FindAllString(source.Content, -1) {
+				if seen[name+value] {
+					continue
+				}
+				seen[name+value] = true
+				secrets = append(secrets, &Secret{Type: name, Value: value, File: source.Path})
+			}
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,79 @@
+package sourcemap
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindURL(t *testing.T) {
+	js := "var a=1;\n//# sourceMappingURL=vendor.js.map\n!function(){}();\n//# sourceMappingURL=app.js.map"
+	if u := FindURL(js, nil); u != "app.js.map" {
+		t.Fatalf("unexpected url %q", u)
+	}
+	if u := FindURL("a{}\n/*# sourceMappingURL=style.css.map */", nil); u != "style.css.map" {
+		t.Fatalf("unexpected css url %q", u)
+	}
+	if u := FindURL(js, map[string]string{"x-sourcemap": "/maps/app.map"}); u != "/maps/app.map" {
+		t.Fatalf("expected header to take precedence, got %q", u)
+	}
+}
+
+func TestSafePath(t *testing.T) {
+	for source, expected := range map[string]string{
+		"webpack:///./src/api/user.js":         "src/api/user.js",
+		"webpack://app/../../../../etc/passwd": "etc/passwd",
+		"../node_modules/lodash/index.js?5f3a": "node_modules/lodash/index.js",
+		"C:\\project\\src\\main.ts":            "project/src/main.ts",
+		"../..":                                "",
+	} {
+		if p := SafePath("", source); p != expected {
+			t.Fatalf("%s: expected %q, got %q", source, expected, p)
+		}
+	}
+	if p := SafePath("/static/", "js/app.js"); p != "static/js/app.js" {
+		t.Fatalf("unexpected path with source root %q", p)
+	}
+}
+
+func TestParse_OriginalSources(t *testing.T) {
+	data := `)]}'
+{"version":3,"sections":[{"offset":{"line":0,"column":0},"map":{"version":3,"sources":["webpack:///src/api.js","webpack:///src/empty.js"],"sourcesContent":["fetch('/api/v1/users?id=1');const apiKey = \"0123456789abcdef\";",null]}},
+{"offset":{"line":1,"column":0},"map":{"version":3,"sources":["webpack:///src/api.js","src/util.js"],"sourcesContent":["duplicated","export default 1"]}}]}`
+	m, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := m.OriginalSources()
+	if len(sources) != 2 || sources[0].Path != "src/api.js" || sources[1].Path != "src/util.js" {
+		t.Fatalf("unexpected sources %+v", sources)
+	}
+	secrets := FindSecrets(sources)
+	if len(secrets) != 1 || secrets[0].Type != "GenericSecret" || secrets[0].File != "src/api.js" {
+		t.Fatalf("unexpected secrets %+v", secrets)
+	}
+	if _, err = Parse([]byte(`{"version":3,"mappings":""}`)); err == nil {
+		t.Fatal("expected error for source map without sources")
+	}
+}
+
+func TestFetch_DataURL(t *testing.T) {
+	data := `{"version":3,"sources":["a.js"],"sourcesContent":["a"]}`
+	m, err := Fetch("data:application/json;charset=utf-8;base64,"+base64.StdEncoding.EncodeToString([]byte(data)), nil, "")
+	if err != nil || len(m.OriginalSources()) != 1 {
+		t.Fatalf("unexpected result %+v %v", m, err)
+	}
+}
+
+func TestSaveTree(t *testing.T) {
+	dir := t.TempDir()
+	files, err := SaveTree(dir, []Source{{Path: "src/api/user.js", Content: "export {}"}})
+	if err != nil || len(files) != 1 {
+		t.Fatalf("unexpected result %v %v", files, err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "src", "api", "user.js"))
+	if err != nil || string(content) != "export {}" {
+		t.Fatalf("unexpected content %q %v", content, err)
+	}
+}
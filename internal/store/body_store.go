@@ -0,0 +1,126 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BodyStore 以SHA-256为键的响应体存储,相同内容只写入一次,便于离线分析
+type BodyStore struct {
+	Dir       string         // 存储目录
+	MaxSizes  map[string]int // 每种MIME类型的最大字节数,键支持 text/html、image/* 和 * 三种形式,0表示不限制
+	AllowMime []string       // 允许存储的MIME类型,为空时允许全部,支持 image/* 形式
+	DenyMime  []string       // 不允许存储的MIME类型,优先于允许列表
+}
+
+// NewBodyStore 创建响应体存储目录
+func NewBodyStore(dir string, maxSizes map[string]int, allowMime []string, denyMime []string) (*BodyStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &BodyStore{Dir: dir, MaxSizes: maxSizes, AllowMime: allowMime, DenyMime: denyMime}, nil
+}
+
+// BodyHash 计算响应体的SHA-256
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Allowed 判断指定MIME类型和大小的响应体是否需要存储
+func (s *BodyStore) Allowed(mimeType string, size int) bool {
+	mimeType = normalizeMime(mimeType)
+	if matchMime(mimeType, s.DenyMime) {
+		return false
+	}
+	if len(s.AllowMime) > 0 && !matchMime(mimeType, s.AllowMime) {
+		return false
+	}
+	if limit := s.MaxSize(mimeType); limit > 0 && size > limit {
+		return false
+	}
+	return true
+}
+
+// MaxSize 获取MIME类型的最大字节数,依次匹配完整类型、主类型通配和 *
+func (s *BodyStore) MaxSize(mimeType string) int {
+	mimeType = normalizeMime(mimeType)
+	if limit, ok := s.MaxSizes[mimeType]; ok {
+		return limit
+	}
+	if limit, ok := s.MaxSizes[strings.SplitN(mimeType, "/", 2)[0]+"/*"]; ok {
+		return limit
+	}
+	return s.MaxSizes["*"]
+}
+
+// Save 存储响应体并返回SHA-256,不需要存储时返回空字符串
+func (s *BodyStore) Save(mimeType string, body []byte) (string, error) {
+	if len(body) == 0 || !s.Allowed(mimeType, len(body)) {
+		return "", nil
+	}
+	hash := BodyHash(body)
+	path := s.Path(hash)
+	// 内容相同的文件已经存在
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	// 先写入临时文件再重命名,避免并发写入时读取到不完整的文件
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmp.Write(body); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return hash, nil
+}
+
+// Load 按照SHA-256读取响应体
+func (s *BodyStore) Load(hash string) ([]byte, error) {
+	return os.ReadFile(s.Path(hash))
+}
+
+// Path 获取响应体的存储路径,按照hash的前两位分目录
+func (s *BodyStore) Path(hash string) string {
+	if len(hash) < 2 {
+		return filepath.Join(s.Dir, hash)
+	}
+	return filepath.Join(s.Dir, hash[:2], hash)
+}
+
+// normalizeMime 去掉MIME类型中的参数并转为小写
+func normalizeMime(mimeType string) string {
+	mimeType = strings.SplitN(mimeType, ";", 2)[0]
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// matchMime 判断MIME类型是否在列表中,支持 image/* 和 * 形式
+func matchMime(mimeType string, list []string) bool {
+	for _, item := range list {
+		item = normalizeMime(item)
+		if item == "*" || item == mimeType {
+			return true
+		}
+		if strings.HasSuffix(item, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(item, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBodyStore_Allowed(t *testing.T) {
+	s := &BodyStore{
+		MaxSizes:  map[string]int{"*": 100, "image/*": 10, "application/json": 0},
+		AllowMime: []string{"text/*", "image/*", "application/json"},
+		DenyMime:  []string{"text/css"},
+	}
+	for _, item := range []struct {
+		mime    string
+		size    int
+		allowed bool
+	}{
+		{"text/html; charset=utf-8", 100, true},
+		{"text/html", 101, false},
+		{"TEXT/CSS", 1, false},
+		{"image/png", 10, true},
+		{"image/png", 11, false},
+		{"application/json", 1 << 20, true},
+		{"application/javascript", 1, false},
+	} {
+		if s.Allowed(item.mime, item.size) != item.allowed {
+			t.Fatalf("%s %d: expected %v", item.mime, item.size, item.allowed)
+		}
+	}
+}
+
+func TestBodyStore_Save(t *testing.T) {
+	s, err := NewBodyStore(t.TempDir(), map[string]int{"*": 1024}, nil, []string{"image/*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte("<html>crawlergo</html>")
+	hash, err := s.Save("text/html", body)
+	if err != nil || hash != BodyHash(body) {
+		t.Fatalf("save failed: %s %v", hash, err)
+	}
+	// 相同内容只存储一份
+	if again, err := s.Save("text/html", body); err != nil || again != hash {
+		t.Fatalf("save again failed: %s %v", again, err)
+	}
+	entries, _ := os.ReadDir(s.Dir + "/" + hash[:2])
+	if len(entries) != 1 {
+		t.Fatalf("expected one stored file, got %d", len(entries))
+	}
+	if loaded, err := s.Load(hash); err != nil || string(loaded) != string(body) {
+		t.Fatalf("load failed: %s %v", loaded, err)
+	}
+	if hash, _ := s.Save("image/png", body); hash != "" {
+		t.Fatal("expected denied mime not to be stored")
+	}
+}
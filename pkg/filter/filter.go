@@ -0,0 +1,42 @@
+package filter
+
+import (
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/filter"
+)
+
+// 对外提供的过滤器注册接口,注册后的过滤器可以通过任务配置的FilterMode按照名称使用
+
+// 内置的过滤模式
+const (
+	ModeSimple = filter.ModeSimple
+	ModeSmart  = filter.ModeSmart
+	ModeStrict = filter.ModeStrict
+)
+
+// Request 需要过滤的爬虫请求
+type Request = httplib.RequestCrawler
+
+// Filter 请求过滤器,DoFilter返回true时丢弃该请求,会被多个tab并发调用
+type Filter = filter.Filter
+
+// Config 创建过滤器的配置
+type Config = filter.Config
+
+// Factory 过滤器的创建函数,每个设备的爬取都会创建新的过滤器
+type Factory = filter.Factory
+
+// Register 按照名称注册过滤器,名称不区分大小写,不能重复注册
+func Register(name string, factory Factory) error {
+	return filter.Register(name, factory)
+}
+
+// New 按照名称创建过滤器,名称为空时使用智能模式
+func New(name string, config Config) (Filter, error) {
+	return filter.New(name, config)
+}
+
+// Names 已经注册的过滤器名称
+func Names() []string {
+	return filter.Names()
+}
@@ -0,0 +1,39 @@
+package filter
+
+import (
+	"fmt"
+	"github.com/sairson/crawlergo/internal/engine/httplib"
+	"github.com/sairson/crawlergo/internal/engine/httplib/urllib"
+	"strings"
+	"testing"
+	"time"
+)
+
+type methodFilter struct {
+	method string
+}
+
+func (m *methodFilter) DoFilter(req *Request) bool {
+	return req.Method != m.method
+}
+
+func TestRegister(t *testing.T) {
+	// 同一个进程中重复执行测试时使用不同的名称
+	name := fmt.Sprintf("post-only-%d", time.Now().UnixNano())
+	if err := Register(name, func(config Config) Filter {
+		return &methodFilter{method: "POST"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := New(strings.ToUpper(name), Config{HostLimit: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := urllib.GetURL("https://example.com/login")
+	if !f.DoFilter(httplib.GetCrawlerRequest("GET", u)) || f.DoFilter(httplib.GetCrawlerRequest("POST", u)) {
+		t.Fatal("registered filter was not used")
+	}
+	if err = Register(ModeSmart, func(config Config) Filter { return nil }); err == nil {
+		t.Fatal("builtin filter should not be replaced")
+	}
+}